@@ -9,7 +9,12 @@ import (
 	"github.com/robstave/link-manager/internal/controllers"
 	"github.com/robstave/link-manager/internal/db"
 	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/platform/blobstore"
+	"github.com/robstave/link-manager/internal/platform/embedding"
+	"github.com/robstave/link-manager/internal/platform/fetch"
 	"github.com/robstave/link-manager/internal/platform/logger"
+	"github.com/robstave/link-manager/internal/platform/netguard"
+	"github.com/robstave/link-manager/internal/platform/summarizer"
 	"github.com/robstave/link-manager/internal/repositories"
 	"github.com/robstave/link-manager/internal/services"
 )
@@ -33,12 +38,57 @@ func main() {
 	}
 
 	authController := controllers.NewAuthController(authSvc)
-	projectController := controllers.NewProjectController(services.NewProjectService(repositories.NewProjectRepository(database.Pool)))
+	linkRepo := repositories.NewLinkRepository(database.Pool)
+	collectionSvc := services.NewSmartCollectionService(repositories.NewSmartCollectionRepository(database.Pool), linkRepo)
+	projectController := controllers.NewProjectController(services.NewProjectService(repositories.NewProjectRepository(database.Pool), collectionSvc))
+	collectionController := controllers.NewSmartCollectionController(collectionSvc)
 	categoryController := controllers.NewCategoryController(services.NewCategoryService(repositories.NewCategoryRepository(database.Pool)))
-	metaSvc := services.NewMetadataService()
-	linkController := controllers.NewLinkController(services.NewLinkService(repositories.NewLinkRepository(database.Pool), metaSvc))
+	fetcher := fetch.New(netguard.New())
+	log.Info("fetch client configured", "userAgent", fetcher.UserAgent())
+	metaSvc := services.NewMetadataService(fetcher, repositories.NewMetadataCacheRepository(database.Pool))
+	go metaSvc.RunCacheJanitor(ctx)
+	embedder := embedding.New()
+	log.Info("embedding provider configured", "model", embedder.Model())
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(database.Pool))
+	linkSvc := services.NewLinkService(linkRepo, metaSvc, embedder, jobQueue)
+	go func() {
+		if err := linkSvc.BackfillDomains(ctx); err != nil {
+			log.Warn("domain backfill failed", "error", err)
+		}
+		if err := linkSvc.BackfillEmbeddings(ctx); err != nil {
+			log.Warn("embedding backfill failed", "error", err)
+		}
+	}()
+	linkController := controllers.NewLinkController(linkSvc)
+	notesSvc := services.NewGeneratedNotesService(repositories.NewGeneratedNotesRepository(database.Pool), linkSvc, metaSvc, summarizer.New(), jobQueue)
+	jobQueue.Register(services.JobEmbedLink, linkSvc.HandleEmbedLinkJob)
+	jobQueue.Register(services.JobEnrichMetadata, linkSvc.HandleEnrichMetadataJob)
+	jobQueue.Register(services.JobGenerateNotes, notesSvc.HandleGenerateNotesJob)
+	iconSvc := services.NewIconService(repositories.NewIconRepository(database.Pool), linkRepo, metaSvc, fetcher)
+	jobQueue.Register(services.JobFetchIcon, iconSvc.HandleFetchIconJob)
+	blobs, err := blobstore.New()
+	if err != nil {
+		log.Error("failed to open blob store", "error", err)
+		os.Exit(1)
+	}
+	thumbnailSvc := services.NewThumbnailService(repositories.NewThumbnailRepository(database.Pool), blobs, jobQueue, fetcher)
+	jobQueue.Register(services.JobFetchThumbnail, thumbnailSvc.HandleFetchThumbnailJob)
+	jobQueue.Register(services.JobBackfillThumbnails, thumbnailSvc.HandleBackfillThumbnailsJob)
+	jobQueue.Start(ctx, 0)
+	go linkSvc.RunMetadataRefresher(ctx)
+	if _, err := thumbnailSvc.QueueBackfill(ctx); err != nil {
+		log.Warn("failed to queue thumbnail backfill", "error", err)
+	}
+	jobController := controllers.NewJobController(jobQueue)
+	notesController := controllers.NewGeneratedNotesController(notesSvc)
 	tagController := controllers.NewTagController(services.NewTagService(repositories.NewTagRepository(database.Pool)))
+	domainController := controllers.NewDomainController(services.NewDomainService(repositories.NewDomainRepository(database.Pool)))
 	metadataController := controllers.NewMetadataController(metaSvc)
+	iconController := controllers.NewIconController(iconSvc)
+	fetchController := controllers.NewFetchController(fetcher)
+	thumbnailController := controllers.NewThumbnailController(thumbnailSvc)
+	exportController := controllers.NewExportController(services.NewExportService(linkSvc, iconSvc))
+	importController := controllers.NewImportController(services.NewImportService(linkSvc, linkRepo, repositories.NewProjectRepository(database.Pool), repositories.NewCategoryRepository(database.Pool), iconSvc))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/v1/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -46,6 +96,8 @@ func main() {
 		fmt.Fprintf(w, `{"status":"ok","db":"connected"}`)
 	})
 	mux.HandleFunc("POST /api/v1/auth/login", authController.Login)
+	mux.HandleFunc("GET /api/v1/icons/{hash}", iconController.Serve)
+	mux.HandleFunc("GET /api/v1/thumbnails/{hash}", thumbnailController.Serve)
 
 	protected := http.NewServeMux()
 	protected.HandleFunc("GET /api/v1/auth/me", authController.Me)
@@ -57,15 +109,42 @@ func main() {
 	protected.HandleFunc("DELETE /api/v1/categories/{id}", categoryController.Delete)
 	protected.HandleFunc("GET /api/v1/links", linkController.List)
 	protected.HandleFunc("POST /api/v1/links", linkController.Create)
+	protected.HandleFunc("GET /api/v1/links/search/semantic", linkController.SemanticSearch)
 	protected.HandleFunc("GET /api/v1/links/{id}", linkController.Get)
+	protected.HandleFunc("GET /api/v1/links/{id}/similar", linkController.Similar)
+	protected.HandleFunc("GET /api/v1/links/{id}/metadata", linkController.MetadataStatus)
 	protected.HandleFunc("PUT /api/v1/links/{id}", linkController.Update)
 	protected.HandleFunc("DELETE /api/v1/links/{id}", linkController.Delete)
+	protected.HandleFunc("POST /api/v1/links/{id}/refresh", linkController.Refresh)
 	protected.HandleFunc("POST /api/v1/links/{id}/click", linkController.Click)
 	protected.HandleFunc("PATCH /api/v1/links/{id}/stars", linkController.UpdateStars)
 	protected.HandleFunc("PATCH /api/v1/links/{id}/cart", linkController.ToggleCart)
+	protected.HandleFunc("POST /api/v1/links/{id}/generate", notesController.Generate)
+	protected.HandleFunc("POST /api/v1/links/{id}/fetch-icon", iconController.FetchForLink)
 	protected.HandleFunc("GET /api/v1/export/links.json", linkController.Export)
+	protected.HandleFunc("GET /api/v1/export/bookmarks.html", exportController.BookmarksHTML)
+	protected.HandleFunc("GET /api/v1/export/obsidian.zip", exportController.ObsidianZip)
+	protected.HandleFunc("POST /api/v1/import", importController.Import)
+	protected.HandleFunc("POST /api/v1/import/bookmarks-html", importController.BookmarksHTML)
+	protected.HandleFunc("GET /api/v1/collections", collectionController.List)
+	protected.HandleFunc("POST /api/v1/collections", collectionController.Create)
+	protected.HandleFunc("GET /api/v1/collections/{id}", collectionController.Get)
+	protected.HandleFunc("PUT /api/v1/collections/{id}", collectionController.Update)
+	protected.HandleFunc("DELETE /api/v1/collections/{id}", collectionController.Delete)
+	protected.HandleFunc("GET /api/v1/collections/{id}/links", collectionController.Links)
 	protected.HandleFunc("GET /api/v1/tags", tagController.List)
+	protected.HandleFunc("GET /api/v1/domains", domainController.List)
+	protected.HandleFunc("GET /api/v1/jobs", jobController.List)
+	protected.HandleFunc("GET /api/v1/jobs/{id}", jobController.Get)
+	protected.HandleFunc("POST /api/v1/jobs/{id}/retry", jobController.Retry)
 	protected.HandleFunc("GET /api/v1/meta/title", metadataController.FetchTitle)
+	protected.HandleFunc("GET /api/v1/meta/preview", metadataController.Preview)
+	protected.HandleFunc("POST /api/v1/meta/batch", metadataController.Batch)
+	protected.Handle("GET /api/v1/admin/icon-overrides", middleware.RequireAdmin(http.HandlerFunc(iconController.ListOverrides)))
+	protected.Handle("PUT /api/v1/admin/icon-overrides/{domain}", middleware.RequireAdmin(http.HandlerFunc(iconController.PutOverride)))
+	protected.Handle("DELETE /api/v1/admin/icon-overrides/{domain}", middleware.RequireAdmin(http.HandlerFunc(iconController.DeleteOverride)))
+	protected.Handle("GET /api/v1/admin/fetch-metrics", middleware.RequireAdmin(http.HandlerFunc(fetchController.Metrics)))
+	protected.Handle("POST /api/v1/admin/thumbnails/backfill", middleware.RequireAdmin(http.HandlerFunc(thumbnailController.Backfill)))
 
 	mux.Handle("/api/v1/", middleware.AuthMiddleware(protected))
 	handler := middleware.CORS(mux)
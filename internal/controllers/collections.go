@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type SmartCollectionController struct {
+	service *services.SmartCollectionService
+}
+
+func NewSmartCollectionController(service *services.SmartCollectionService) *SmartCollectionController {
+	return &SmartCollectionController{service: service}
+}
+
+type SmartCollectionRequest struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Filters     models.SmartFilter `json:"filters"`
+}
+
+func (c *SmartCollectionController) List(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	items, err := c.service.List(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, "failed to fetch collections", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (c *SmartCollectionController) Get(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	item, err := c.service.Get(r.Context(), claims.UserID, r.PathValue("id"))
+	if services.IsNotFound(err) {
+		http.Error(w, "collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch collection", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (c *SmartCollectionController) Create(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	var req SmartCollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	item, err := c.service.Create(r.Context(), claims.UserID, req.Name, req.Description, req.Filters)
+	if errors.Is(err, services.ErrInvalidSmartFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to create collection", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+func (c *SmartCollectionController) Update(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	var req SmartCollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	item, err := c.service.Update(r.Context(), claims.UserID, r.PathValue("id"), req.Name, req.Description, req.Filters)
+	if errors.Is(err, services.ErrInvalidSmartFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if services.IsNotFound(err) {
+		http.Error(w, "collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to update collection", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (c *SmartCollectionController) Delete(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	err := c.service.Delete(r.Context(), claims.UserID, r.PathValue("id"))
+	if services.IsNotFound(err) {
+		http.Error(w, "collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to delete collection", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *SmartCollectionController) Links(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	limit, offset := 50, 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 200 {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	items, total, err := c.service.Links(r.Context(), claims.UserID, r.PathValue("id"), r.URL.Query().Get("sort"), limit, offset)
+	if services.IsNotFound(err) {
+		http.Error(w, "collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch links: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := make([]LinkResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, toResponse(it))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LinksListResponse{Links: resp, Total: total, Limit: limit, Offset: offset})
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type DomainController struct{ service *services.DomainService }
+
+func NewDomainController(service *services.DomainService) *DomainController {
+	return &DomainController{service: service}
+}
+
+func (c *DomainController) List(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	domains, err := c.service.List(r.Context(), claims.UserID, r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, "failed to fetch domains", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domains)
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type ExportController struct {
+	service *services.ExportService
+}
+
+func NewExportController(service *services.ExportService) *ExportController {
+	return &ExportController{service: service}
+}
+
+// BookmarksHTML exports the links matching the GET /links filters as a
+// Netscape bookmarks.html. It is built in memory so a failure part way
+// through still gets a proper error status.
+func (c *ExportController) BookmarksHTML(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	filters, err := linkFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var buf bytes.Buffer
+	if err := c.service.WriteBookmarksHTML(r.Context(), claims.UserID, filters, &buf); err != nil {
+		http.Error(w, "failed to export bookmarks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=bookmarks.html")
+	w.Write(buf.Bytes())
+}
+
+// ObsidianZip exports the links matching the GET /links filters as a zip of
+// Markdown notes for an Obsidian vault.
+func (c *ExportController) ObsidianZip(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	filters, err := linkFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var buf bytes.Buffer
+	if err := c.service.WriteObsidianZip(r.Context(), claims.UserID, filters, &buf); err != nil {
+		http.Error(w, "failed to export notes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=obsidian.zip")
+	w.Write(buf.Bytes())
+}
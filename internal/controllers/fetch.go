@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/robstave/link-manager/internal/platform/fetch"
+)
+
+type FetchController struct {
+	fetcher *fetch.Client
+}
+
+func NewFetchController(fetcher *fetch.Client) *FetchController {
+	return &FetchController{fetcher: fetcher}
+}
+
+// Metrics reports the shared fetch client's counters since startup.
+func (c *FetchController) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.fetcher.Metrics())
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type GeneratedNotesController struct {
+	service *services.GeneratedNotesService
+}
+
+func NewGeneratedNotesController(service *services.GeneratedNotesService) *GeneratedNotesController {
+	return &GeneratedNotesController{service: service}
+}
+
+func (c *GeneratedNotesController) Generate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		size = "short"
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	result, err := c.service.Generate(r.Context(), claims.UserID, r.PathValue("id"), size, force)
+	if errors.Is(err, services.ErrInvalidNotesSize) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if services.IsNotFound(err) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to generate notes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status == services.NotesStatusProcessing {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"status": result.Status, "job_id": result.JobID})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"generated_notes": result.Notes, "size": result.Size})
+}
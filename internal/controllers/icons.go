@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type IconController struct {
+	service *services.IconService
+}
+
+func NewIconController(service *services.IconService) *IconController {
+	return &IconController{service: service}
+}
+
+// FetchForLink fetches and stores the link's icon now instead of waiting for
+// enrichment.
+func (c *IconController) FetchForLink(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	iconURL, err := c.service.FetchForLink(r.Context(), claims.UserID, r.PathValue("id"))
+	if services.IsNotFound(err) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrIconNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch icon", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"icon_url": iconURL})
+}
+
+// Serve returns a stored icon. Icons are addressed by content hash, so they
+// never change and can be cached forever. It is public: <img> tags cannot
+// send the bearer token.
+func (c *IconController) Serve(w http.ResponseWriter, r *http.Request) {
+	hash := r.PathValue("hash")
+	if !isHexHash(hash) {
+		http.Error(w, "icon not found", http.StatusNotFound)
+		return
+	}
+	etag := `"` + hash + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	icon, err := c.service.Get(r.Context(), hash)
+	if services.IsNotFound(err) {
+		http.Error(w, "icon not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch icon", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", icon.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(icon.Data)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// SVGs can carry script; never let one run if opened directly.
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	w.Write(icon.Data)
+}
+
+func isHexHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *IconController) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := c.service.ListOverrides(r.Context())
+	if err != nil {
+		http.Error(w, "failed to fetch icon overrides", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(overrides)
+}
+
+func (c *IconController) PutOverride(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IconURL string `json:"icon_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	override, err := c.service.PutOverride(r.Context(), r.PathValue("domain"), req.IconURL)
+	if errors.Is(err, services.ErrInvalidIconOverride) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to save icon override", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(override)
+}
+
+func (c *IconController) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	err := c.service.DeleteOverride(r.Context(), r.PathValue("domain"))
+	if services.IsNotFound(err) {
+		http.Error(w, "icon override not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to delete icon override", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
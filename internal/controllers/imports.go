@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+// maxImportBytes caps an uploaded export file.
+const maxImportBytes = 32 << 20
+
+type ImportController struct {
+	service *services.ImportService
+}
+
+func NewImportController(service *services.ImportService) *ImportController {
+	return &ImportController{service: service}
+}
+
+// BookmarksHTML imports a browser's bookmarks.html. ?dry_run=true returns
+// the report without creating anything.
+func (c *ImportController) BookmarksHTML(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	body, err := importFile(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+	report, err := c.service.ImportBookmarksHTML(r.Context(), claims.UserID, body, importOptions(r))
+	writeImportReport(w, report, err)
+}
+
+// Import imports an export file in the ?format= named format: netscape,
+// pocket, raindrop, pinboard or linkding.
+func (c *ImportController) Import(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	format := r.URL.Query().Get("format")
+	if formats := c.service.Formats(); !slices.Contains(formats, format) {
+		http.Error(w, "format must be one of "+strings.Join(formats, ", "), http.StatusBadRequest)
+		return
+	}
+	body, err := importFile(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+	report, err := c.service.ImportFile(r.Context(), claims.UserID, format, body, importOptions(r))
+	writeImportReport(w, report, err)
+}
+
+func importOptions(r *http.Request) services.ImportOptions {
+	return services.ImportOptions{
+		ProjectID: r.URL.Query().Get("project_id"),
+		DryRun:    r.URL.Query().Get("dry_run") == "true",
+	}
+}
+
+// importFile returns the uploaded file: the "file" field of a multipart
+// form, or else the raw request body.
+func importFile(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.New("multipart upload needs a file field")
+	}
+	return file, nil
+}
+
+func writeImportReport(w http.ResponseWriter, report services.ImportReport, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(w, "import file too large", http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, services.ErrInvalidImportFile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case services.IsNotFound(err):
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "failed to import: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
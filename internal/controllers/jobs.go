@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/robstave/link-manager/internal/auth"
+	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type JobController struct{ queue *services.JobQueue }
+
+func NewJobController(queue *services.JobQueue) *JobController {
+	return &JobController{queue: queue}
+}
+
+// jobScope returns the owner filter for job queries: admins see every job,
+// including system jobs without an owner.
+func jobScope(claims *auth.Claims) string {
+	if claims.Role == "admin" {
+		return ""
+	}
+	return claims.UserID
+}
+
+func (c *JobController) List(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	q := r.URL.Query()
+	limit := 50
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 200 {
+		limit = v
+	}
+	jobs, err := c.queue.List(r.Context(), jobScope(claims), q.Get("status"), q.Get("kind"), limit)
+	if err != nil {
+		http.Error(w, "failed to fetch jobs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+func (c *JobController) Get(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	job, err := c.queue.Get(r.Context(), r.PathValue("id"), jobScope(claims))
+	if services.IsNotFound(err) {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch job", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// Retry requeues a dead-lettered job.
+func (c *JobController) Retry(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	job, err := c.queue.Retry(r.Context(), r.PathValue("id"), jobScope(claims))
+	if services.IsNotFound(err) {
+		http.Error(w, "job not found or not dead", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to retry job", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
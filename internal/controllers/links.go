@@ -2,10 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/robstave/link-manager/internal/middleware"
+	"github.com/robstave/link-manager/internal/platform/readability"
 	"github.com/robstave/link-manager/internal/repositories"
 	"github.com/robstave/link-manager/internal/services"
 )
@@ -37,35 +41,53 @@ type CategoryInfo struct {
 }
 
 type LinkResponse struct {
-	ID                 string        `json:"id"`
-	OwnerID            string        `json:"owner_id"`
-	ProjectID          string        `json:"project_id"`
-	CategoryID         string        `json:"category_id"`
-	URL                string        `json:"url"`
-	Title              string        `json:"title"`
-	Description        string        `json:"description"`
-	IconURL            string        `json:"icon_url"`
-	UserNotes          string        `json:"user_notes"`
-	GeneratedNotes     string        `json:"generated_notes"`
-	GeneratedNotesSize string        `json:"generated_notes_size"`
-	Stars              int           `json:"stars"`
-	ClickCount         int           `json:"click_count"`
-	LastClickedAt      any           `json:"last_clicked_at,omitempty"`
-	Cart               bool          `json:"cart"`
-	CreatedAt          any           `json:"created_at"`
-	UpdatedAt          any           `json:"updated_at"`
-	Tags               []string      `json:"tags,omitempty"`
-	Project            *ProjectInfo  `json:"project,omitempty"`
-	Category           *CategoryInfo `json:"category,omitempty"`
+	ID                 string            `json:"id"`
+	OwnerID            string            `json:"owner_id"`
+	ProjectID          string            `json:"project_id"`
+	CategoryID         string            `json:"category_id"`
+	URL                string            `json:"url"`
+	Domain             string            `json:"domain"`
+	Title              string            `json:"title"`
+	Description        string            `json:"description"`
+	IconURL            string            `json:"icon_url"`
+	UserNotes          string            `json:"user_notes"`
+	GeneratedNotes     string            `json:"generated_notes"`
+	GeneratedNotesSize string            `json:"generated_notes_size"`
+	MetadataStatus     string            `json:"metadata_status"`
+	SiteName           string            `json:"site_name"`
+	Author             string            `json:"author"`
+	PublishedAt        *time.Time        `json:"published_at,omitempty"`
+	ContentType        string            `json:"content_type"`
+	DurationSeconds    *int              `json:"duration_seconds,omitempty"`
+	ImageURL           string            `json:"image_url"`
+	ThumbnailURL       string            `json:"thumbnail_url"`
+	SiteData           map[string]string `json:"site_data,omitempty"`
+	WordCount          int               `json:"word_count"`
+	ReadingTimeMinutes int               `json:"reading_time_minutes"`
+	MimeType           string            `json:"mime_type"`
+	ContentLength      *int64            `json:"content_length,omitempty"`
+	MetadataFetchedAt  *time.Time        `json:"metadata_fetched_at,omitempty"`
+	UserEditedFields   []string          `json:"user_edited_fields"`
+	Stars              int               `json:"stars"`
+	ClickCount         int               `json:"click_count"`
+	LastClickedAt      any               `json:"last_clicked_at,omitempty"`
+	Cart               bool              `json:"cart"`
+	CreatedAt          any               `json:"created_at"`
+	UpdatedAt          any               `json:"updated_at"`
+	Tags               []string          `json:"tags,omitempty"`
+	Project            *ProjectInfo      `json:"project,omitempty"`
+	Category           *CategoryInfo     `json:"category,omitempty"`
+	SearchScore        float64           `json:"search_score,omitempty"`
 }
 
 type LinksListResponse struct {
 	Links                []LinkResponse `json:"links"`
 	Total, Limit, Offset int
+	Facets               *repositories.LinkFacets `json:"facets,omitempty"`
 }
 
 func toResponse(item repositories.LinkWithMeta) LinkResponse {
-	return LinkResponse{ID: item.ID, OwnerID: item.OwnerID, ProjectID: item.ProjectID, CategoryID: item.CategoryID, URL: item.URL, Title: item.Title, Description: item.Description, IconURL: item.IconURL, UserNotes: item.UserNotes, GeneratedNotes: item.GeneratedNotes, GeneratedNotesSize: item.GeneratedNotesSize, Stars: item.Stars, ClickCount: item.ClickCount, LastClickedAt: item.LastClickedAt, Cart: item.Cart, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt, Tags: item.Tags, Project: &ProjectInfo{ID: item.ProjectID, Name: item.ProjectName}, Category: &CategoryInfo{ID: item.CategoryID, Name: item.CategoryName}}
+	return LinkResponse{ID: item.ID, OwnerID: item.OwnerID, ProjectID: item.ProjectID, CategoryID: item.CategoryID, URL: item.URL, Domain: item.Domain, Title: item.Title, Description: item.Description, IconURL: item.IconURL, UserNotes: item.UserNotes, GeneratedNotes: item.GeneratedNotes, GeneratedNotesSize: item.GeneratedNotesSize, MetadataStatus: item.MetadataStatus, SiteName: item.SiteName, Author: item.Author, PublishedAt: item.PublishedAt, ContentType: item.ContentType, DurationSeconds: item.DurationSeconds, ImageURL: item.ImageURL, ThumbnailURL: item.ThumbnailURL, SiteData: item.SiteData, WordCount: item.WordCount, ReadingTimeMinutes: readability.ReadingMinutes(item.WordCount), MimeType: item.MimeType, ContentLength: item.ContentLength, MetadataFetchedAt: item.MetadataFetchedAt, UserEditedFields: item.UserEditedFields, Stars: item.Stars, ClickCount: item.ClickCount, LastClickedAt: item.LastClickedAt, Cart: item.Cart, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt, Tags: item.Tags, Project: &ProjectInfo{ID: item.ProjectID, Name: item.ProjectName}, Category: &CategoryInfo{ID: item.CategoryID, Name: item.CategoryName}, SearchScore: item.SearchScore}
 }
 
 func (c *LinkController) List(w http.ResponseWriter, r *http.Request) {
@@ -73,6 +95,14 @@ func (c *LinkController) List(w http.ResponseWriter, r *http.Request) {
 	sortBy := r.URL.Query().Get("sort")
 	if sortBy == "" {
 		sortBy = "stars"
+		if r.URL.Query().Get("q") != "" {
+			sortBy = "relevance"
+		}
+	}
+	filters, err := linkFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	limit, offset := 50, 0
 	if l := r.URL.Query().Get("limit"); l != "" {
@@ -86,7 +116,8 @@ func (c *LinkController) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	items, total, err := c.service.List(r.Context(), claims.UserID, repositories.LinkFilters{ProjectID: r.URL.Query().Get("project_id"), CategoryID: r.URL.Query().Get("category_id"), Tag: r.URL.Query().Get("tag"), Cart: r.URL.Query().Get("cart"), Search: r.URL.Query().Get("q"), SortBy: sortBy, Limit: limit, Offset: offset})
+	filters.SortBy, filters.Limit, filters.Offset = sortBy, limit, offset
+	items, total, err := c.service.List(r.Context(), claims.UserID, filters)
 	if err != nil {
 		http.Error(w, "failed to fetch links: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -96,8 +127,76 @@ func (c *LinkController) List(w http.ResponseWriter, r *http.Request) {
 	for _, it := range items {
 		resp = append(resp, toResponse(it))
 	}
+	list := LinksListResponse{Links: resp, Total: total, Limit: limit, Offset: offset}
+	if r.URL.Query().Get("facets") == "true" {
+		facets, err := c.service.Facets(r.Context(), claims.UserID, filters)
+		if err != nil {
+			http.Error(w, "failed to compute facets: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		list.Facets = &facets
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (c *LinkController) SemanticSearch(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "q parameter is required", http.StatusBadRequest)
+		return
+	}
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	items, err := c.service.SemanticSearch(r.Context(), claims.UserID, q, limit)
+	if err != nil {
+		http.Error(w, "failed to search links: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := make([]LinkResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, toResponse(it))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LinksListResponse{Links: resp, Total: len(resp), Limit: limit})
+}
+
+type SimilarLinkResponse struct {
+	LinkResponse
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+func (c *LinkController) Similar(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	items, err := c.service.Similar(r.Context(), r.PathValue("id"), claims.UserID, limit)
+	if services.IsNotFound(err) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to find similar links: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := make([]SimilarLinkResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, SimilarLinkResponse{LinkResponse: toResponse(it.LinkWithMeta), Score: it.Score, Reasons: it.Reasons})
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(LinksListResponse{Links: resp, Total: total, Limit: limit, Offset: offset})
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (c *LinkController) Create(w http.ResponseWriter, r *http.Request) {
@@ -143,6 +242,28 @@ func (c *LinkController) Get(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(toResponse(item))
 }
 
+// MetadataStatus is polled by the UI after saving a link until background
+// enrichment finishes.
+func (c *LinkController) MetadataStatus(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	item, err := c.service.Get(r.Context(), r.PathValue("id"), claims.UserID)
+	if services.IsNotFound(err) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch link", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"metadata_status": item.MetadataStatus,
+		"title":           item.Title,
+		"description":     item.Description,
+		"icon_url":        item.IconURL,
+	})
+}
+
 func (c *LinkController) Update(w http.ResponseWriter, r *http.Request) {
 	claims, _ := middleware.GetUserClaims(r.Context())
 	var req CreateLinkRequest
@@ -173,6 +294,10 @@ func (c *LinkController) Update(w http.ResponseWriter, r *http.Request) {
 		Tags:        req.Tags,
 		Stars:       req.Stars,
 	})
+	if services.IsNotFound(err) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to update link: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -180,6 +305,38 @@ func (c *LinkController) Update(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Refresh queues a metadata refetch. ?reset=title,description hands
+// user-edited fields back to enrichment.
+func (c *LinkController) Refresh(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.GetUserClaims(r.Context())
+	var reset []string
+	if v := r.URL.Query().Get("reset"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			reset = append(reset, strings.TrimSpace(f))
+		}
+	}
+	job, err := c.service.Refresh(r.Context(), claims.UserID, r.PathValue("id"), reset)
+	if errors.Is(err, services.ErrInvalidEditedField) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if services.IsNotFound(err) {
+		http.Error(w, "link not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrMetadataUnavailable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to queue refresh: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"metadata_status": services.MetadataStatusPending, "job_id": job.ID})
+}
+
 func (c *LinkController) Click(w http.ResponseWriter, r *http.Request) {
 	claims, _ := middleware.GetUserClaims(r.Context())
 	url, err := c.service.Click(r.Context(), r.PathValue("id"), claims.UserID)
@@ -240,9 +397,25 @@ func (c *LinkController) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// linkFilters reads the GET /links filter parameters shared with the
+// exports; sorting and paging are left to the caller.
+func linkFilters(r *http.Request) (repositories.LinkFilters, error) {
+	q := r.URL.Query()
+	contentType := q.Get("content_type")
+	if contentType != "" && !services.ValidContentType(contentType) {
+		return repositories.LinkFilters{}, errors.New("content_type must be one of article, video, repo, paper, image, document, website")
+	}
+	return repositories.LinkFilters{ProjectID: q.Get("project_id"), CategoryID: q.Get("category_id"), Tag: q.Get("tag"), Cart: q.Get("cart"), Domain: q.Get("domain"), ContentType: contentType, Search: q.Get("q")}, nil
+}
+
 func (c *LinkController) Export(w http.ResponseWriter, r *http.Request) {
 	claims, _ := middleware.GetUserClaims(r.Context())
-	items, err := c.service.Export(r.Context(), claims.UserID)
+	filters, err := linkFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := c.service.Export(r.Context(), claims.UserID, filters)
 	if err != nil {
 		http.Error(w, "failed to fetch links for export: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/netguard"
 	"github.com/robstave/link-manager/internal/services"
 )
 
@@ -22,7 +25,15 @@ func (c *MetadataController) FetchTitle(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	title, err := c.service.FetchTitle(url)
+	title, err := c.service.FetchTitle(r.Context(), url)
+	if errors.Is(err, netguard.ErrBlocked) {
+		http.Error(w, "url not allowed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, fetch.ErrDisallowed) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to fetch title: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -31,3 +42,87 @@ func (c *MetadataController) FetchTitle(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"title": title})
 }
+
+func (c *MetadataController) Preview(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "url parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := c.service.Preview(r.Context(), url)
+	if err != nil {
+		msg, code := previewError(err)
+		http.Error(w, msg, code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+type BatchPreviewRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// BatchPreviewLine is one NDJSON line of a batch response. Status is the
+// code a single /meta/preview call would have returned.
+type BatchPreviewLine struct {
+	Index   int                   `json:"index"`
+	URL     string                `json:"url"`
+	Status  int                   `json:"status"`
+	Preview *services.LinkPreview `json:"preview,omitempty"`
+	Error   string                `json:"error,omitempty"`
+}
+
+// Batch previews many URLs at once and streams one NDJSON line per URL as
+// each finishes.
+func (c *MetadataController) Batch(w http.ResponseWriter, r *http.Request) {
+	var req BatchPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.URLs) == 0 {
+		http.Error(w, "urls is required", http.StatusBadRequest)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	started := false
+	err := c.service.PreviewBatch(r.Context(), req.URLs, func(res services.BatchPreviewResult) {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		line := BatchPreviewLine{Index: res.Index, URL: res.URL, Status: http.StatusOK}
+		if res.Err != nil {
+			line.Error, line.Status = previewError(res.Err)
+		} else {
+			line.Preview = &res.Preview
+		}
+		if err := enc.Encode(line); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if errors.Is(err, services.ErrBatchTooLarge) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// previewError maps a preview failure to a message and status code.
+func previewError(err error) (string, int) {
+	switch {
+	case errors.Is(err, netguard.ErrBlocked):
+		return "url not allowed: " + err.Error(), http.StatusBadRequest
+	case errors.Is(err, fetch.ErrDisallowed):
+		return err.Error(), http.StatusForbidden
+	default:
+		return "failed to fetch preview: " + err.Error(), http.StatusBadGateway
+	}
+}
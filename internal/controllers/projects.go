@@ -22,7 +22,11 @@ type CreateProjectRequest struct {
 
 func (c *ProjectController) List(w http.ResponseWriter, r *http.Request) {
 	claims, _ := middleware.GetUserClaims(r.Context())
-	projects, err := c.service.List(r.Context(), claims.UserID)
+	list := c.service.List
+	if r.URL.Query().Get("include_virtual") == "true" {
+		list = c.service.ListWithCollections
+	}
+	projects, err := list(r.Context(), claims.UserID)
 	if err != nil {
 		http.Error(w, "failed to fetch projects", http.StatusInternalServerError)
 		return
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/robstave/link-manager/internal/platform/blobstore"
+	"github.com/robstave/link-manager/internal/services"
+)
+
+type ThumbnailController struct {
+	service *services.ThumbnailService
+}
+
+func NewThumbnailController(service *services.ThumbnailService) *ThumbnailController {
+	return &ThumbnailController{service: service}
+}
+
+// Serve returns a stored thumbnail, ?size=small|medium|large (default
+// medium). Like icons, thumbnails are content-addressed, cacheable forever
+// and public so <img> tags can load them.
+func (c *ThumbnailController) Serve(w http.ResponseWriter, r *http.Request) {
+	hash := r.PathValue("hash")
+	if !isHexHash(hash) {
+		http.Error(w, "thumbnail not found", http.StatusNotFound)
+		return
+	}
+	size := r.URL.Query().Get("size")
+	etag := `"` + hash + "-" + size + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	data, err := c.service.Get(r.Context(), hash, size)
+	if errors.Is(err, services.ErrInvalidThumbnailSize) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, blobstore.ErrNotFound) {
+		http.Error(w, "thumbnail not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to fetch thumbnail", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+}
+
+// Backfill queues thumbnail generation for every link that has a preview
+// image but no thumbnail attempt yet.
+func (c *ThumbnailController) Backfill(w http.ResponseWriter, r *http.Request) {
+	job, err := c.service.QueueBackfill(r.Context())
+	if err != nil {
+		http.Error(w, "failed to queue backfill", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"job_id": job.ID})
+}
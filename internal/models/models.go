@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID           string    `json:"id"`
@@ -39,12 +42,27 @@ type Link struct {
 	ProjectID           string     `json:"project_id"`
 	CategoryID          string     `json:"category_id"`
 	URL                 string     `json:"url"`
+	Domain              string     `json:"domain"`
 	Title               string     `json:"title"`
 	Description         string     `json:"description"`
 	IconURL             string     `json:"icon_url"`
 	UserNotes           string     `json:"user_notes"`
 	GeneratedNotes      string     `json:"generated_notes"`
 	GeneratedNotesSize  string     `json:"generated_notes_size"`
+	MetadataStatus      string     `json:"metadata_status"`
+	SiteName            string     `json:"site_name"`
+	Author              string     `json:"author"`
+	PublishedAt         *time.Time `json:"published_at,omitempty"`
+	ContentType         string     `json:"content_type"`
+	DurationSeconds     *int       `json:"duration_seconds,omitempty"`
+	ImageURL            string     `json:"image_url"`
+	ThumbnailURL        string     `json:"thumbnail_url"`
+	SiteData            SiteData   `json:"site_data,omitempty"`
+	WordCount           int        `json:"word_count"`
+	MimeType            string     `json:"mime_type"`
+	ContentLength       *int64     `json:"content_length,omitempty"`
+	MetadataFetchedAt   *time.Time `json:"metadata_fetched_at,omitempty"`
+	UserEditedFields    []string   `json:"user_edited_fields"`
 	Stars               int        `json:"stars"`
 	ClickCount          int        `json:"click_count"`
 	LastClickedAt       *time.Time `json:"last_clicked_at,omitempty"`
@@ -56,6 +74,10 @@ type Link struct {
 	CategoryName        string     `json:"category_name,omitempty"`
 }
 
+// SiteData holds site-specific attributes found by metadata extractors, such
+// as a GitHub repo's stars or an arXiv paper's ID.
+type SiteData map[string]string
+
 type Tag struct {
 	ID        string    `json:"id"`
 	OwnerID   string    `json:"owner_id"`
@@ -64,3 +86,56 @@ type Tag struct {
 	CreatedAt time.Time `json:"created_at"`
 	LinkCount int       `json:"link_count,omitempty"`
 }
+
+// SmartFilter is a saved link query. It extends the GET /links filters with
+// star ranges, relative date windows, click thresholds and tag sets. Day
+// windows are relative so a collection stays current without being edited.
+type SmartFilter struct {
+	ProjectID         string   `json:"project_id,omitempty"`
+	CategoryID        string   `json:"category_id,omitempty"`
+	Tag               string   `json:"tag,omitempty"`
+	Cart              string   `json:"cart,omitempty"`
+	Domain            string   `json:"domain,omitempty"`
+	ContentType       string   `json:"content_type,omitempty"`
+	Search            string   `json:"q,omitempty"`
+	SortBy            string   `json:"sort,omitempty"`
+	MinStars          *int     `json:"min_stars,omitempty"`
+	MaxStars          *int     `json:"max_stars,omitempty"`
+	MinClicks         *int     `json:"min_clicks,omitempty"`
+	MaxClicks         *int     `json:"max_clicks,omitempty"`
+	AddedWithinDays   *int     `json:"added_within_days,omitempty"`
+	AddedBeforeDays   *int     `json:"added_before_days,omitempty"`
+	VisitedWithinDays *int     `json:"visited_within_days,omitempty"`
+	NotVisitedDays    *int     `json:"not_visited_days,omitempty"`
+	TagsAll           []string `json:"tags_all,omitempty"`
+	TagsAny           []string `json:"tags_any,omitempty"`
+	TagsNone          []string `json:"tags_none,omitempty"`
+}
+
+type SmartCollection struct {
+	ID           string      `json:"id"`
+	OwnerID      string      `json:"owner_id"`
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	Filters      SmartFilter `json:"filters"`
+	IsBuiltin    bool        `json:"is_builtin"`
+	DisplayOrder int         `json:"display_order"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type Job struct {
+	ID          string          `json:"id"`
+	OwnerID     *string         `json:"owner_id,omitempty"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,39 @@
+// Package blobstore keeps generated binary files (preview thumbnails) outside
+// the database. Store is the extension point: the filesystem implementation
+// is the only one today, and an object store can be added behind the same
+// interface and picked with BLOB_STORE.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store saves and loads blobs by key. Keys are slash-separated relative paths
+// such as "thumbnails/ab12.../small.jpg".
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound (wrapped) for a missing key.
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// New builds the store named by BLOB_STORE (default "fs"):
+//
+//	fs  files under BLOB_DIR (default "data/blobs")
+func New() (Store, error) {
+	switch kind := strings.TrimSpace(os.Getenv("BLOB_STORE")); kind {
+	case "", "fs":
+		dir := strings.TrimSpace(os.Getenv("BLOB_DIR"))
+		if dir == "" {
+			dir = "data/blobs"
+		}
+		return NewFilesystem(dir)
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", kind)
+	}
+}
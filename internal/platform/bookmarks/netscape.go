@@ -0,0 +1,181 @@
+// Package bookmarks reads and writes the Netscape bookmark file format
+// (bookmarks.html) that every browser imports and exports.
+package bookmarks
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrNotBookmarkFile = errors.New("not a Netscape bookmark file")
+
+// Bookmark is one <A> entry. Folders is the path of enclosing <H3> folders,
+// outermost first.
+type Bookmark struct {
+	URL         string
+	Title       string
+	Description string
+	Folders     []string
+	Tags        []string
+	AddedAt     *time.Time
+	// Icon is the ICON attribute, normally a data: URI; IconURI is ICON_URI,
+	// where the browser got it.
+	Icon    string
+	IconURI string
+}
+
+// Parse reads a bookmark file. The format is loose HTML (unclosed <DT> and
+// <p>, upper-case tags), so it is tokenized rather than parsed as a tree:
+// every <H3> names the <DL> that follows it, and a <DD> right after an <A>
+// is that bookmark's description.
+func Parse(r io.Reader) ([]Bookmark, error) {
+	z := html.NewTokenizer(r)
+	var (
+		out     []Bookmark
+		stack   []string // one entry per open <DL>; "" for unnamed lists
+		pending string   // folder name waiting for its <DL>
+		seenDL  bool
+
+		inTitle, inFolder, inDesc bool
+		text                      strings.Builder
+		current                   *Bookmark
+	)
+	flush := func() {
+		switch {
+		case inTitle:
+			current.Title = cleanText(text.String())
+		case inFolder:
+			pending = cleanText(text.String())
+		case inDesc:
+			current.Description = cleanText(text.String())
+		}
+		inTitle, inFolder, inDesc = false, false, false
+		text.Reset()
+	}
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			flush()
+			if !seenDL {
+				return nil, ErrNotBookmarkFile
+			}
+			return out, nil
+
+		case html.TextToken:
+			if inTitle || inFolder || inDesc {
+				text.Write(z.Text())
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Dl:
+				flush()
+				seenDL = true
+				stack = append(stack, pending)
+				pending = ""
+			case atom.H3:
+				flush()
+				inFolder = true
+			case atom.A:
+				flush()
+				b := Bookmark{Folders: folderPath(stack)}
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					applyAttr(&b, string(key), string(val))
+				}
+				out = append(out, b)
+				current = &out[len(out)-1]
+				inTitle = true
+			case atom.Dd:
+				flush()
+				// Only a description when it follows a bookmark; after an
+				// <H3> it describes the folder.
+				if current != nil && pending == "" {
+					inDesc = true
+				}
+			case atom.Dt:
+				flush()
+				current = nil
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.A, atom.H3:
+				flush()
+			case atom.Dl:
+				flush()
+				current = nil
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+}
+
+func folderPath(stack []string) []string {
+	var path []string
+	for _, name := range stack {
+		if name != "" {
+			path = append(path, name)
+		}
+	}
+	return path
+}
+
+func applyAttr(b *Bookmark, key, val string) {
+	switch key {
+	case "href":
+		b.URL = strings.TrimSpace(val)
+	case "add_date":
+		b.AddedAt = parseTimestamp(val)
+	case "tags":
+		for _, tag := range strings.Split(val, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				b.Tags = append(b.Tags, tag)
+			}
+		}
+	case "icon":
+		b.Icon = val
+	case "icon_uri":
+		b.IconURI = strings.TrimSpace(val)
+	}
+}
+
+// parseTimestamp reads a Unix time. Browsers write seconds, but some tools
+// write milliseconds or microseconds.
+func parseTimestamp(v string) *time.Time {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	var t time.Time
+	switch {
+	case n > 1e15:
+		t = time.UnixMicro(n)
+	case n > 1e12:
+		t = time.UnixMilli(n)
+	default:
+		t = time.Unix(n, 0)
+	}
+	t = t.UTC()
+	return &t
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
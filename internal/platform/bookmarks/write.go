@@ -0,0 +1,88 @@
+package bookmarks
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const header = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+`
+
+// folder is a node of the tree Write builds from the bookmarks' paths.
+type folder struct {
+	name      string
+	folders   []*folder
+	bookmarks []Bookmark
+}
+
+func (f *folder) child(name string) *folder {
+	for _, c := range f.folders {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &folder{name: name}
+	f.folders = append(f.folders, c)
+	return c
+}
+
+// Write writes items as a bookmark file, nesting each under its Folders
+// path. Folders and bookmarks keep the order they first appear in, with a
+// folder's bookmarks before its subfolders.
+func Write(w io.Writer, items []Bookmark) error {
+	root := &folder{}
+	for _, b := range items {
+		f := root
+		for _, name := range b.Folders {
+			f = f.child(name)
+		}
+		f.bookmarks = append(f.bookmarks, b)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(header)
+	writeFolder(bw, root, 0)
+	return bw.Flush()
+}
+
+func writeFolder(w *bufio.Writer, f *folder, depth int) {
+	indent := strings.Repeat("    ", depth)
+	w.WriteString(indent + "<DL><p>\n")
+	for _, b := range f.bookmarks {
+		writeBookmark(w, b, indent+"    ")
+	}
+	for _, c := range f.folders {
+		w.WriteString(indent + "    <DT><H3>" + html.EscapeString(c.name) + "</H3>\n")
+		writeFolder(w, c, depth+1)
+	}
+	w.WriteString(indent + "</DL><p>\n")
+}
+
+func writeBookmark(w *bufio.Writer, b Bookmark, indent string) {
+	w.WriteString(indent + `<DT><A HREF="` + html.EscapeString(b.URL) + `"`)
+	if b.AddedAt != nil {
+		w.WriteString(` ADD_DATE="` + strconv.FormatInt(b.AddedAt.Unix(), 10) + `"`)
+	}
+	if b.IconURI != "" {
+		w.WriteString(` ICON_URI="` + html.EscapeString(b.IconURI) + `"`)
+	}
+	if b.Icon != "" {
+		w.WriteString(` ICON="` + html.EscapeString(b.Icon) + `"`)
+	}
+	if len(b.Tags) > 0 {
+		w.WriteString(` TAGS="` + html.EscapeString(strings.Join(b.Tags, ",")) + `"`)
+	}
+	w.WriteString(">" + html.EscapeString(b.Title) + "</A>\n")
+	if b.Description != "" {
+		w.WriteString(indent + "<DD>" + html.EscapeString(b.Description) + "\n")
+	}
+}
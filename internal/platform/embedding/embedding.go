@@ -0,0 +1,49 @@
+package embedding
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Dimensions is the width of the links.embedding vector column. Providers
+// must return vectors of exactly this size.
+const Dimensions = 1536
+
+// Embedder turns text into fixed-size vectors for semantic search.
+type Embedder interface {
+	// Model identifies the embedding space; vectors from different models
+	// are never compared with each other.
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New returns an OpenAI-compatible embedder when EMBEDDING_API_URL is set,
+// otherwise the deterministic local embedder.
+func New() Embedder {
+	baseURL := os.Getenv("EMBEDDING_API_URL")
+	if baseURL == "" {
+		return NewLocal()
+	}
+	model := os.Getenv("EMBEDDING_MODEL")
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+	return NewOpenAI(baseURL, os.Getenv("EMBEDDING_API_KEY"), model)
+}
+
+// VectorLiteral formats a vector in pgvector's text representation so it can
+// be passed as a query parameter and cast with ::vector.
+func VectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// LocalEmbedder is a deterministic feature-hashing embedder. It needs no
+// network or model weights, so it works offline and in tests. Similarity is
+// lexical (shared words and character trigrams) rather than truly semantic.
+type LocalEmbedder struct{}
+
+func NewLocal() *LocalEmbedder { return &LocalEmbedder{} }
+
+func (e *LocalEmbedder) Model() string { return "local-hash-v1" }
+
+func (e *LocalEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = hashEmbed(text)
+	}
+	return out, nil
+}
+
+func hashEmbed(text string) []float32 {
+	vec := make([]float32, Dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		addFeature(vec, "w:"+w, 1.0)
+		padded := " " + w + " "
+		runes := []rune(padded)
+		for j := 0; j+3 <= len(runes); j++ {
+			addFeature(vec, "g:"+string(runes[j:j+3]), 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vec
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for j := range vec {
+		vec[j] *= scale
+	}
+	return vec
+}
+
+// addFeature hashes a feature into a bucket with a pseudo-random sign so that
+// collisions cancel out on average instead of accumulating.
+func addFeature(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	idx := int(sum % uint64(len(vec)))
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vec[idx] += weight
+}
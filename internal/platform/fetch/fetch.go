@@ -0,0 +1,140 @@
+// Package fetch is the shared HTTP client for requests made on users' behalf:
+// page metadata, icons and anything else that visits user-supplied URLs. On
+// top of the netguard policy it identifies itself with an honest user agent,
+// honours robots.txt, limits the request rate per host and keeps counters.
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robstave/link-manager/internal/platform/netguard"
+)
+
+// DefaultUserAgent names the bot and where to read about it.
+const DefaultUserAgent = "LinkManagerBot/1.0 (+https://github.com/robstave/link-manager)"
+
+// ErrDisallowed is returned (wrapped) when robots.txt forbids a URL.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Client is safe for concurrent use and meant to be shared, so that rate
+// limits and the robots.txt cache apply across every caller.
+type Client struct {
+	guard     *netguard.Guard
+	userAgent string
+	robots    *robotsCache
+	limiter   *hostLimiter
+	metrics   *metrics
+	transport http.RoundTripper
+}
+
+// Options configures a Client. Zero values take the defaults.
+type Options struct {
+	UserAgent string
+	// RatePerHost is the sustained requests per second to one host, Burst
+	// how many may go out at once after a quiet spell.
+	RatePerHost float64
+	Burst       int
+	// IgnoreRobots skips robots.txt.
+	IgnoreRobots bool
+}
+
+func NewClient(guard *netguard.Guard, opts Options) *Client {
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.RatePerHost <= 0 {
+		opts.RatePerHost = 1
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = 5
+	}
+	c := &Client{
+		guard:     guard,
+		userAgent: opts.UserAgent,
+		limiter:   newHostLimiter(opts.RatePerHost, opts.Burst),
+		metrics:   newMetrics(),
+	}
+	c.transport = &politeTransport{client: c}
+	// robots.txt itself is fetched through the transport, so set it first.
+	if !opts.IgnoreRobots {
+		c.robots = newRobotsCache(c)
+	}
+	return c
+}
+
+// New builds a Client from the environment:
+//
+//	FETCH_USER_AGENT      User-Agent header (default DefaultUserAgent)
+//	FETCH_RATE_PER_HOST   requests per second to one host (default 1)
+//	FETCH_BURST_PER_HOST  requests allowed at once per host (default 5)
+//	FETCH_RESPECT_ROBOTS  "false" skips robots.txt checks (default true)
+func New(guard *netguard.Guard) *Client {
+	rate, _ := strconv.ParseFloat(os.Getenv("FETCH_RATE_PER_HOST"), 64)
+	burst, _ := strconv.Atoi(os.Getenv("FETCH_BURST_PER_HOST"))
+	ignoreRobots := os.Getenv("FETCH_RESPECT_ROBOTS") == "false"
+	if ignoreRobots {
+		slog.Warn("fetch: FETCH_RESPECT_ROBOTS=false, robots.txt is ignored")
+	}
+	return NewClient(guard, Options{
+		UserAgent:    strings.TrimSpace(os.Getenv("FETCH_USER_AGENT")),
+		RatePerHost:  rate,
+		Burst:        burst,
+		IgnoreRobots: ignoreRobots,
+	})
+}
+
+// Guard is the network policy requests go through.
+func (c *Client) Guard() *netguard.Guard { return c.guard }
+
+// UserAgent is the User-Agent header sent with every request.
+func (c *Client) UserAgent() string { return c.userAgent }
+
+// Transport returns the client's round tripper: robots.txt check, per-host
+// rate limit and user agent, then the guard's transport.
+func (c *Client) Transport() http.RoundTripper { return c.transport }
+
+// HTTPClient returns an http.Client using Transport, with redirects checked
+// by the guard.
+func (c *Client) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: c.transport, CheckRedirect: c.guard.CheckRedirect, Timeout: timeout}
+}
+
+// Metrics returns a snapshot of the client's counters.
+func (c *Client) Metrics() Metrics {
+	return c.metrics.snapshot(c.limiter.size())
+}
+
+type politeTransport struct{ client *Client }
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.client
+	start := time.Now()
+	// Refused URLs shouldn't cost a robots.txt fetch or a token.
+	if err := c.guard.CheckURL(req.URL); err != nil {
+		c.metrics.observe(nil, err, 0)
+		return nil, err
+	}
+	if c.robots != nil && req.Context().Value(robotsFetchKey{}) == nil {
+		if !c.robots.allowed(req.Context(), req.URL) {
+			c.metrics.robotsDenied.Add(1)
+			return nil, fmt.Errorf("%w: %s", ErrDisallowed, req.URL.Redacted())
+		}
+	}
+	waited, err := c.limiter.wait(req.Context(), req.URL.Host)
+	c.metrics.observeWait(waited)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.guard.Transport().RoundTrip(req)
+	c.metrics.observe(resp, err, time.Since(start)-waited)
+	return resp, err
+}
@@ -0,0 +1,85 @@
+package fetch
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter is a token bucket per host. A request takes a token, waiting
+// for one to accrue when the bucket is empty; waits queue up in arrival
+// order because each reservation drives the balance further negative.
+type hostLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// idleBuckets are dropped once this many hosts are tracked; a bucket idle
+// long enough to refill is the same as a new one.
+const idleBuckets = 1024
+
+func newHostLimiter(rate float64, burst int) *hostLimiter {
+	return &hostLimiter{rate: rate, burst: float64(burst), buckets: map[string]*bucket{}}
+}
+
+// wait blocks until host may be contacted and returns how long it waited.
+func (l *hostLimiter) wait(ctx context.Context, host string) (time.Duration, error) {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	l.mu.Lock()
+	if len(l.buckets) >= idleBuckets {
+		l.prune(now)
+	}
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[host] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		// Hand the reservation back.
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return time.Since(now), ctx.Err()
+	}
+}
+
+func (l *hostLimiter) prune(now time.Time) {
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for host, b := range l.buckets {
+		if now.Sub(b.last) > full && b.tokens >= 0 {
+			delete(l.buckets, host)
+		}
+	}
+}
+
+func (l *hostLimiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
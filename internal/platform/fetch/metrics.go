@@ -0,0 +1,94 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robstave/link-manager/internal/platform/netguard"
+)
+
+// Metrics are cumulative counters since startup.
+type Metrics struct {
+	Requests      int64            `json:"requests"`
+	Errors        int64            `json:"errors"`
+	Blocked       int64            `json:"blocked"`
+	RobotsDenied  int64            `json:"robots_denied"`
+	Status        map[string]int64 `json:"status"`
+	AvgLatencyMS  float64          `json:"avg_latency_ms"`
+	RateLimited   int64            `json:"rate_limited"`
+	RateWaitMS    int64            `json:"rate_wait_ms"`
+	HostsTracked  int              `json:"hosts_tracked"`
+	StartedAt     time.Time        `json:"started_at"`
+	LastRequestAt *time.Time       `json:"last_request_at,omitempty"`
+}
+
+type metrics struct {
+	requests, errors, blocked, robotsDenied atomic.Int64
+	rateLimited, rateWaitMS                 atomic.Int64
+	latencyMS                               atomic.Int64
+	lastRequest                             atomic.Int64 // unix nanoseconds
+	started                                 time.Time
+
+	mu     sync.Mutex
+	status map[string]int64 // "2xx", "3xx", ...
+}
+
+func newMetrics() *metrics {
+	return &metrics{started: time.Now(), status: map[string]int64{}}
+}
+
+func (m *metrics) observeWait(d time.Duration) {
+	if d > 0 {
+		m.rateLimited.Add(1)
+		m.rateWaitMS.Add(d.Milliseconds())
+	}
+}
+
+func (m *metrics) observe(resp *http.Response, err error, latency time.Duration) {
+	m.requests.Add(1)
+	m.latencyMS.Add(latency.Milliseconds())
+	m.lastRequest.Store(time.Now().UnixNano())
+	if err != nil {
+		if errors.Is(err, netguard.ErrBlocked) {
+			m.blocked.Add(1)
+		} else {
+			m.errors.Add(1)
+		}
+		return
+	}
+	class := strconv.Itoa(resp.StatusCode/100) + "xx"
+	m.mu.Lock()
+	m.status[class]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) snapshot(hosts int) Metrics {
+	out := Metrics{
+		Requests:     m.requests.Load(),
+		Errors:       m.errors.Load(),
+		Blocked:      m.blocked.Load(),
+		RobotsDenied: m.robotsDenied.Load(),
+		RateLimited:  m.rateLimited.Load(),
+		RateWaitMS:   m.rateWaitMS.Load(),
+		HostsTracked: hosts,
+		StartedAt:    m.started,
+		Status:       map[string]int64{},
+	}
+	if out.Requests > 0 {
+		out.AvgLatencyMS = float64(m.latencyMS.Load()) / float64(out.Requests)
+	}
+	if ns := m.lastRequest.Load(); ns > 0 {
+		t := time.Unix(0, ns)
+		out.LastRequestAt = &t
+	}
+	m.mu.Lock()
+	for k, v := range m.status {
+		out.Status[k] = v
+	}
+	m.mu.Unlock()
+	return out
+}
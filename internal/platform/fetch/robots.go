@@ -0,0 +1,160 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+const (
+	// robotsTTL is how long a fetched robots.txt is trusted.
+	robotsTTL = 24 * time.Hour
+	// robotsRetry is how long an unreachable robots.txt counts as allow-all.
+	robotsRetry = 10 * time.Minute
+	// robotsTimeout bounds a robots.txt fetch, which outlives its caller.
+	robotsTimeout = 10 * time.Second
+	// robotsMaxBytes caps the file; Google reads the first 500 KiB.
+	robotsMaxBytes = 500 << 10
+	// robotsMaxHosts bounds the cache.
+	robotsMaxHosts = 4096
+)
+
+// robotsCache fetches robots.txt once per origin and answers from memory.
+// Lookups for an origin being fetched wait for that fetch.
+type robotsCache struct {
+	client *http.Client
+	agent  string
+
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	ready   chan struct{}
+	data    *robotstxt.RobotsData // nil allows everything
+	expires time.Time
+}
+
+func newRobotsCache(c *Client) *robotsCache {
+	// Group matching uses the product token, e.g. "LinkManagerBot".
+	agent, _, _ := strings.Cut(c.userAgent, "/")
+	return &robotsCache{
+		client:  c.HTTPClient(robotsTimeout),
+		agent:   strings.TrimSpace(agent),
+		entries: map[string]*robotsEntry{},
+	}
+}
+
+// allowed reports whether robots.txt for u's origin permits fetching u.
+func (r *robotsCache) allowed(ctx context.Context, u *url.URL) bool {
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+	now := time.Now()
+
+	r.mu.Lock()
+	e, ok := r.entries[origin]
+	if ok {
+		select {
+		case <-e.ready:
+			if now.After(e.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+	owner := !ok
+	if owner {
+		if len(r.entries) >= robotsMaxHosts {
+			r.prune(now)
+		}
+		e = &robotsEntry{ready: make(chan struct{})}
+		r.entries[origin] = e
+	}
+	r.mu.Unlock()
+
+	if owner {
+		// Other callers wait on this fetch, so it must not end with the
+		// context of whoever happened to start it.
+		go func() {
+			e.data, e.expires = r.fetch(context.WithoutCancel(ctx), origin)
+			close(e.ready)
+		}()
+	}
+	select {
+	case <-e.ready:
+	case <-ctx.Done():
+		return true
+	}
+	if e.data == nil {
+		return true
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return e.data.TestAgent(path, r.agent)
+}
+
+// fetch downloads and parses an origin's robots.txt. Missing files (4xx)
+// allow everything, as the standard says. Unreachable files and server
+// errors also allow everything, for a short while, rather than blocking a
+// site over an outage. A fetch cut short by its context is not cached at all.
+func (r *robotsCache) fetch(ctx context.Context, origin string) (*robotstxt.RobotsData, time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, robotsTimeout)
+	defer cancel()
+	// Redirects may lead elsewhere; none of it is checked against robots.txt.
+	ctx = context.WithValue(ctx, robotsFetchKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, time.Now().Add(robotsRetry)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		slog.Info("fetch: robots.txt unavailable", "origin", origin, "error", err)
+		if ctx.Err() != nil {
+			return nil, time.Now()
+		}
+		return nil, time.Now().Add(robotsRetry)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		slog.Info("fetch: robots.txt server error", "origin", origin, "status", resp.StatusCode)
+		return nil, time.Now().Add(robotsRetry)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, robotsMaxBytes))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, time.Now()
+		}
+		return nil, time.Now().Add(robotsRetry)
+	}
+	data, err := robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+	if err != nil {
+		slog.Info("fetch: robots.txt unparseable", "origin", origin, "error", err)
+		return nil, time.Now().Add(robotsTTL)
+	}
+	return data, time.Now().Add(robotsTTL)
+}
+
+type robotsFetchKey struct{}
+
+func (r *robotsCache) prune(now time.Time) {
+	for origin, e := range r.entries {
+		select {
+		case <-e.ready:
+			if now.After(e.expires) {
+				delete(r.entries, origin)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/robstave/link-manager/internal/platform/netguard"
+)
+
+// A caller giving up must not leave the origin cached as allow-all.
+func TestRobotsFetchOutlivesCanceledCaller(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			<-release
+			io.WriteString(w, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	defer close(release)
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	// Loopback counts as public here; 10.0.0.0/8 stands in for private.
+	g := netguard.NewGuard(netguard.Options{Deny: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Ports: []int{port}})
+	client := NewClient(g, Options{RatePerHost: 100}).HTTPClient(5 * time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/private", nil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled caller: err = %v, want context.Canceled", err)
+	}
+
+	release <- struct{}{}
+	if _, err := client.Get(srv.URL + "/private"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("later caller: err = %v, want ErrDisallowed", err)
+	}
+	resp, err := client.Get(srv.URL + "/public")
+	if err != nil {
+		t.Fatalf("allowed path: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,216 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+var errBadICO = errors.New("invalid ICO file")
+
+// maxICOSide bounds the width and height of an ICO entry, embedded PNGs
+// included, so a tiny file can't declare an image that needs gigabytes to
+// decode. Real icons are at most 256 pixels.
+const maxICOSide = 1024
+
+func isICO(data []byte) bool {
+	return len(data) >= 6 && data[0] == 0 && data[1] == 0 && data[2] == 1 && data[3] == 0
+}
+
+type icoEntry struct {
+	width, height int
+	bitCount      int
+	size, offset  uint32
+}
+
+// DecodeICO decodes the largest image in a Windows icon file. Entries may be
+// embedded PNGs or headerless BMPs (1, 4, 8, 24 or 32 bits per pixel, with an
+// AND transparency mask).
+func DecodeICO(data []byte) (image.Image, error) {
+	payload, err := icoPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(payload, []byte("\x89PNG")) {
+		if _, _, err := pngSize(payload); err != nil {
+			return nil, err
+		}
+		return png.Decode(bytes.NewReader(payload))
+	}
+	return decodeDIB(payload)
+}
+
+// icoSize returns the size of the entry DecodeICO would decode, reading
+// only headers.
+func icoSize(data []byte) (width, height int, err error) {
+	payload, err := icoPayload(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	if bytes.HasPrefix(payload, []byte("\x89PNG")) {
+		return pngSize(payload)
+	}
+	w, h, _, err := dibHeader(payload)
+	return w, h, err
+}
+
+// pngSize reads an embedded PNG's header, rejecting sizes over maxICOSide.
+func pngSize(payload []byte) (width, height int, err error) {
+	cfg, err := png.DecodeConfig(bytes.NewReader(payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxICOSide || cfg.Height > maxICOSide {
+		return 0, 0, errBadICO
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// icoPayload returns the bytes of the largest entry in an icon file.
+func icoPayload(data []byte) ([]byte, error) {
+	if !isICO(data) {
+		return nil, errBadICO
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || len(data) < 6+16*count {
+		return nil, errBadICO
+	}
+
+	var best *icoEntry
+	for i := 0; i < count; i++ {
+		e := data[6+16*i : 6+16*(i+1)]
+		entry := icoEntry{
+			width:    int(e[0]),
+			height:   int(e[1]),
+			bitCount: int(binary.LittleEndian.Uint16(e[6:8])),
+			size:     binary.LittleEndian.Uint32(e[8:12]),
+			offset:   binary.LittleEndian.Uint32(e[12:16]),
+		}
+		// A stored dimension of 0 means 256.
+		if entry.width == 0 {
+			entry.width = 256
+		}
+		if entry.height == 0 {
+			entry.height = 256
+		}
+		if uint64(entry.offset)+uint64(entry.size) > uint64(len(data)) {
+			continue
+		}
+		if best == nil || entry.width > best.width || (entry.width == best.width && entry.bitCount > best.bitCount) {
+			best = &entry
+		}
+	}
+	if best == nil {
+		return nil, errBadICO
+	}
+	return data[best.offset : best.offset+best.size], nil
+}
+
+// dibHeader reads and checks the size of a BITMAPINFOHEADER bitmap. The
+// stored height is doubled to cover the AND mask.
+func dibHeader(b []byte) (width, height, headerSize int, err error) {
+	if len(b) < 40 {
+		return 0, 0, 0, errBadICO
+	}
+	headerSize = int(binary.LittleEndian.Uint32(b[0:4]))
+	w := int(int32(binary.LittleEndian.Uint32(b[4:8])))
+	h := int(int32(binary.LittleEndian.Uint32(b[8:12]))) / 2
+	if w <= 0 || h <= 0 || w > maxICOSide || h > maxICOSide || headerSize < 40 || headerSize > len(b) {
+		return 0, 0, 0, errBadICO
+	}
+	return w, h, headerSize, nil
+}
+
+// decodeDIB decodes a BITMAPINFOHEADER bitmap as stored in ICO files: rows
+// bottom-up, height doubled to cover the XOR image plus the AND mask.
+func decodeDIB(b []byte) (image.Image, error) {
+	w, h, headerSize, err := dibHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	bpp := int(binary.LittleEndian.Uint16(b[14:16]))
+	compression := binary.LittleEndian.Uint32(b[16:20])
+	colorsUsed := int(binary.LittleEndian.Uint32(b[32:36]))
+	if compression != 0 && compression != 3 {
+		return nil, fmt.Errorf("%w: compressed BMP", ErrUnsupported)
+	}
+
+	pos := headerSize
+	var palette []color.NRGBA
+	if bpp <= 8 {
+		n := colorsUsed
+		if n == 0 {
+			n = 1 << bpp
+		}
+		if pos+4*n > len(b) {
+			return nil, errBadICO
+		}
+		for i := 0; i < n; i++ {
+			p := b[pos+4*i:]
+			palette = append(palette, color.NRGBA{R: p[2], G: p[1], B: p[0], A: 0xff})
+		}
+		pos += 4 * n
+	}
+
+	switch bpp {
+	case 1, 4, 8, 24, 32:
+	default:
+		return nil, fmt.Errorf("%w: %d bpp BMP", ErrUnsupported, bpp)
+	}
+	stride := ((w*bpp + 31) / 32) * 4
+	maskStride := ((w + 31) / 32) * 4
+	if pos+stride*h > len(b) {
+		return nil, errBadICO
+	}
+	maskStart := pos + stride*h
+	hasMask := maskStart+maskStride*h <= len(b)
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	anyAlpha := false
+	for y := 0; y < h; y++ {
+		row := b[pos+(h-1-y)*stride:]
+		for x := 0; x < w; x++ {
+			var c color.NRGBA
+			switch bpp {
+			case 32:
+				c = color.NRGBA{R: row[4*x+2], G: row[4*x+1], B: row[4*x], A: row[4*x+3]}
+				if c.A != 0 {
+					anyAlpha = true
+				}
+			case 24:
+				c = color.NRGBA{R: row[3*x+2], G: row[3*x+1], B: row[3*x], A: 0xff}
+			default:
+				perByte := 8 / bpp
+				shift := uint(8 - bpp*(x%perByte+1))
+				idx := int(row[x/perByte]>>shift) & (1<<bpp - 1)
+				if idx < len(palette) {
+					c = palette[idx]
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// 32-bit entries carry real alpha; the AND mask only matters when they
+	// don't, or for lower bit depths.
+	if hasMask && (bpp != 32 || !anyAlpha) {
+		for y := 0; y < h; y++ {
+			row := b[maskStart+(h-1-y)*maskStride:]
+			for x := 0; x < w; x++ {
+				transparent := row[x/8]&(0x80>>uint(x%8)) != 0
+				c := img.NRGBAAt(x, y)
+				if transparent {
+					c.A = 0
+				} else if bpp == 32 {
+					c.A = 0xff
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,60 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// pngHeader returns just the signature and IHDR of a PNG declaring w×h,
+// which is all DecodeConfig reads; decoding it would need w*h*4 bytes.
+func pngHeader(w, h uint32) []byte {
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12], ihdr[13] = 8, 6 // 8-bit RGBA
+	binary.Write(&b, binary.BigEndian, uint32(13))
+	b.Write(ihdr)
+	binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return b.Bytes()
+}
+
+// ico wraps one PNG payload in an icon file.
+func ico(payload []byte) []byte {
+	b := []byte{0, 0, 1, 0, 1, 0}
+	entry := make([]byte, 16)
+	binary.LittleEndian.PutUint16(entry[6:], 32)
+	binary.LittleEndian.PutUint32(entry[8:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(entry[12:], 22)
+	return append(append(b, entry...), payload...)
+}
+
+func TestDimensionsReadsHeadersOnly(t *testing.T) {
+	w, h, format, err := Dimensions(pngHeader(20000, 20000))
+	if err != nil || w != 20000 || h != 20000 || format != "png" {
+		t.Fatalf("png: got %dx%d %q, %v", w, h, format, err)
+	}
+
+	var small bytes.Buffer
+	png.Encode(&small, image.NewNRGBA(image.Rect(0, 0, 48, 32)))
+	w, h, format, err = Dimensions(ico(small.Bytes()))
+	if err != nil || w != 48 || h != 32 || format != "ico" {
+		t.Fatalf("ico: got %dx%d %q, %v", w, h, format, err)
+	}
+}
+
+func TestICORejectsHugeEmbeddedPNG(t *testing.T) {
+	data := ico(pngHeader(65535, 65535))
+	if _, _, _, err := Dimensions(data); err == nil {
+		t.Error("Dimensions accepted a 65535x65535 entry")
+	}
+	if _, _, err := Decode(data); err == nil {
+		t.Error("Decode accepted a 65535x65535 entry")
+	}
+}
@@ -0,0 +1,136 @@
+// Package imaging decodes, resizes and encodes the small raster images the
+// app stores itself (favicons, preview thumbnails) using only the standard
+// library. ICO files are supported in addition to PNG, JPEG and GIF.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Decode decodes a PNG, JPEG, GIF or ICO image and returns it with its format
+// name. For ICO files the largest entry is used.
+func Decode(data []byte) (image.Image, string, error) {
+	if isICO(data) {
+		img, err := DecodeICO(data)
+		return img, "ico", err
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, "", ErrUnsupported
+	}
+	return img, format, err
+}
+
+// Dimensions returns an image's size and format from its headers, without
+// decoding the pixels. For ICO files it is the entry Decode would use.
+func Dimensions(data []byte) (width, height int, format string, err error) {
+	if isICO(data) {
+		w, h, err := icoSize(data)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		return w, h, "ico", nil
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return 0, 0, "", ErrUnsupported
+	}
+	return cfg.Width, cfg.Height, format, err
+}
+
+// Fit scales img down so that neither side exceeds max pixels, keeping the
+// aspect ratio. Images already small enough are copied unscaled.
+func Fit(img image.Image, max int) *image.NRGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > max || sh > max {
+		if sw >= sh {
+			dw, dh = max, sh*max/sw
+		} else {
+			dw, dh = sw*max/sh, max
+		}
+	}
+	return Resize(img, max1(dw), max1(dh))
+}
+
+// Resize scales img to exactly w×h. Downscaling averages every source pixel
+// covered by a destination pixel (a box filter); upscaling samples the
+// nearest source pixel.
+func Resize(img image.Image, w, h int) *image.NRGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for dy := 0; dy < h; dy++ {
+		y0 := b.Min.Y + dy*sh/h
+		y1 := b.Min.Y + max1((dy+1)*sh/h)
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < w; dx++ {
+			x0 := b.Min.X + dx*sw/w
+			x1 := b.Min.X + max1((dx+1)*sw/w)
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			// Average in premultiplied space so transparent pixels don't
+			// darken edges.
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			r, g, bl, a = r/n, g/n, bl/n, a/n
+			c := color.NRGBA64{}
+			if a > 0 {
+				c = color.NRGBA64{R: uint16(r * 0xffff / a), G: uint16(g * 0xffff / a), B: uint16(bl * 0xffff / a), A: uint16(a)}
+			}
+			dst.Set(dx, dy, c)
+		}
+	}
+	return dst
+}
+
+// Flatten draws img over an opaque background, for encoders without alpha
+// such as JPEG, which would otherwise turn transparent areas black.
+func Flatten(img image.Image, bg color.Color) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	return buf.Bytes(), err
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
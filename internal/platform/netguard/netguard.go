@@ -0,0 +1,405 @@
+// Package netguard keeps server-side fetches of user-supplied URLs away from
+// internal networks. Every connection is checked after DNS resolution, at
+// dial time, so redirects and DNS rebinding cannot reach a denied address.
+// Through a proxy the destination is resolved and checked before the request
+// instead, since only the proxy is dialed.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ErrBlocked is returned (wrapped) for any URL or connection the guard refuses.
+var ErrBlocked = errors.New("destination not allowed")
+
+// ErrTooLarge is returned when a response body exceeds the size cap.
+var ErrTooLarge = errors.New("response body too large")
+
+// defaultDeny covers loopback, private, link-local (including the cloud
+// metadata address 169.254.169.254), CGNAT, multicast and reserved ranges.
+var defaultDeny = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// Guard is the policy applied to outbound fetches.
+type Guard struct {
+	deny         []netip.Prefix
+	schemes      map[string]bool
+	ports        map[int]bool
+	maxBodyBytes int64
+	maxRedirects int
+	proxy        func(*url.URL) (*url.URL, error)
+
+	transportOnce sync.Once
+	transport     http.RoundTripper
+}
+
+// Options configures a Guard. Zero values take the defaults.
+type Options struct {
+	// Deny lists CIDRs to refuse. Nil means the built-in private/reserved list.
+	Deny         []netip.Prefix
+	Schemes      []string
+	Ports        []int
+	MaxBodyBytes int64
+	MaxRedirects int
+	// Proxy picks a proxy per destination, like http.Transport.Proxy. Nil
+	// connects directly.
+	Proxy func(*url.URL) (*url.URL, error)
+}
+
+func NewGuard(opts Options) *Guard {
+	g := &Guard{
+		deny:         opts.Deny,
+		schemes:      map[string]bool{},
+		ports:        map[int]bool{},
+		maxBodyBytes: opts.MaxBodyBytes,
+		maxRedirects: opts.MaxRedirects,
+		proxy:        opts.Proxy,
+	}
+	if g.deny == nil {
+		for _, cidr := range defaultDeny {
+			g.deny = append(g.deny, netip.MustParsePrefix(cidr))
+		}
+	}
+	if len(opts.Schemes) == 0 {
+		opts.Schemes = []string{"http", "https"}
+	}
+	for _, s := range opts.Schemes {
+		g.schemes[strings.ToLower(s)] = true
+	}
+	if len(opts.Ports) == 0 {
+		opts.Ports = []int{80, 443, 8080, 8443}
+	}
+	for _, p := range opts.Ports {
+		g.ports[p] = true
+	}
+	if g.maxBodyBytes <= 0 {
+		g.maxBodyBytes = 5 << 20
+	}
+	if g.maxRedirects <= 0 {
+		g.maxRedirects = 10
+	}
+	return g
+}
+
+// New builds a Guard from the environment:
+//
+//	FETCH_DENY_CIDRS     extra CIDRs appended to the built-in deny list
+//	FETCH_ALLOW_PRIVATE  "true" drops the built-in list (local development)
+//	FETCH_ALLOWED_PORTS  comma-separated ports (default 80,443,8080,8443)
+//	FETCH_MAX_BYTES      response size cap in bytes (default 5 MiB)
+//
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY are honoured. The proxies themselves
+// may be on a private network.
+func New() *Guard {
+	var deny []netip.Prefix
+	if os.Getenv("FETCH_ALLOW_PRIVATE") != "true" {
+		for _, cidr := range defaultDeny {
+			deny = append(deny, netip.MustParsePrefix(cidr))
+		}
+	} else {
+		slog.Warn("netguard: FETCH_ALLOW_PRIVATE set, private networks are reachable")
+		deny = []netip.Prefix{}
+	}
+	for _, cidr := range splitList(os.Getenv("FETCH_DENY_CIDRS")) {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			slog.Warn("netguard: ignoring invalid CIDR", "value", cidr, "error", err)
+			continue
+		}
+		deny = append(deny, prefix)
+	}
+	var ports []int
+	for _, p := range splitList(os.Getenv("FETCH_ALLOWED_PORTS")) {
+		if n, err := strconv.Atoi(p); err == nil && n > 0 && n < 65536 {
+			ports = append(ports, n)
+		}
+	}
+	maxBytes, _ := strconv.ParseInt(os.Getenv("FETCH_MAX_BYTES"), 10, 64)
+	var proxy func(*url.URL) (*url.URL, error)
+	if cfg := httpproxy.FromEnvironment(); cfg.HTTPProxy != "" || cfg.HTTPSProxy != "" {
+		proxy = cfg.ProxyFunc()
+		slog.Info("netguard: using proxy from environment", "http", redactProxy(cfg.HTTPProxy), "https", redactProxy(cfg.HTTPSProxy))
+	}
+	return NewGuard(Options{Deny: deny, Ports: ports, MaxBodyBytes: maxBytes, Proxy: proxy})
+}
+
+// MaxBodyBytes is the response size cap.
+func (g *Guard) MaxBodyBytes() int64 { return g.maxBodyBytes }
+
+type maxBodyKey struct{}
+
+// WithMaxBodyBytes returns a context whose requests use n as the response
+// size cap instead of the guard's default, for callers that expect larger
+// bodies such as documents.
+func WithMaxBodyBytes(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, maxBodyKey{}, n)
+}
+
+func (g *Guard) bodyLimit(ctx context.Context) int64 {
+	if n, ok := ctx.Value(maxBodyKey{}).(int64); ok && n > 0 {
+		return n
+	}
+	return g.maxBodyBytes
+}
+
+// CheckURL validates scheme and port, and the host when it is an IP literal.
+// Hostnames are checked when dialed.
+func (g *Guard) CheckURL(u *url.URL) error {
+	if !g.schemes[strings.ToLower(u.Scheme)] {
+		return fmt.Errorf("%w: scheme %q", ErrBlocked, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlocked)
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%w: %s", ErrBlocked, host)
+	}
+	port, err := urlPort(u)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	if !g.ports[port] {
+		return fmt.Errorf("%w: port %d", ErrBlocked, port)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddr(addr)
+	}
+	return nil
+}
+
+// CheckRawURL parses and checks a URL string.
+func (g *Guard) CheckRawURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	return g.CheckURL(u)
+}
+
+func (g *Guard) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range g.deny {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: address %s is in %s", ErrBlocked, addr, prefix)
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control hook. It runs after name resolution for
+// each address actually dialed.
+func (g *Guard) Control(network, address string, _ syscall.RawConn) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: unresolved address %s", ErrBlocked, host)
+	}
+	port, _ := strconv.Atoi(portStr)
+	if !g.ports[port] {
+		return fmt.Errorf("%w: port %d", ErrBlocked, port)
+	}
+	return g.checkAddr(addr)
+}
+
+// CheckRedirect is an http.Client CheckRedirect that re-validates each hop.
+func (g *Guard) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= g.maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", g.maxRedirects)
+	}
+	return g.CheckURL(req.URL)
+}
+
+// checkHost resolves a hostname and checks every address, for requests that
+// go through a proxy and so are never dialed here.
+func (g *Guard) checkHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddr(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := g.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type proxiedKey struct{}
+
+// Transport returns the guard's shared round tripper: connections are checked
+// by Control and response bodies are capped. A proxied request dials only the
+// proxy, which is trusted; its destination is checked in RoundTrip.
+func (g *Guard) Transport() http.RoundTripper {
+	g.transportOnce.Do(func() {
+		guarded := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: g.Control}
+		plain := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+		g.transport = &limitTransport{
+			guard: g,
+			base: &http.Transport{
+				Proxy: func(req *http.Request) (*url.URL, error) {
+					if g.proxy == nil {
+						return nil, nil
+					}
+					return g.proxy(req.URL)
+				},
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					if ctx.Value(proxiedKey{}) != nil {
+						return plain.DialContext(ctx, network, addr)
+					}
+					return guarded.DialContext(ctx, network, addr)
+				},
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: 1 * time.Second,
+			},
+		}
+	})
+	return g.transport
+}
+
+// Client returns an http.Client using Transport and CheckRedirect.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: g.Transport(), CheckRedirect: g.CheckRedirect, Timeout: timeout}
+}
+
+// limitTransport checks each request URL and caps response bodies.
+type limitTransport struct {
+	guard *Guard
+	base  http.RoundTripper
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	if t.guard.proxy != nil {
+		proxyURL, err := t.guard.proxy(req.URL)
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL != nil {
+			if err := t.guard.checkHost(req.Context(), req.URL.Hostname()); err != nil {
+				return nil, err
+			}
+			req = req.WithContext(context.WithValue(req.Context(), proxiedKey{}, true))
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	limit := t.guard.bodyLimit(req.Context())
+	if resp.ContentLength > limit {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{rc: resp.Body, remaining: limit}
+	return resp, nil
+}
+
+type limitedBody struct {
+	rc        io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Distinguish "exactly at the cap" from "over it".
+		var one [1]byte
+		if n, _ := b.rc.Read(one[:]); n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.rc.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error { return b.rc.Close() }
+
+func urlPort(u *url.URL) (int, error) {
+	if p := u.Port(); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("invalid port %q", p)
+		}
+		return n, nil
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return 80, nil
+	case "https":
+		return 443, nil
+	}
+	return 0, fmt.Errorf("no default port for scheme %q", u.Scheme)
+}
+
+// redactProxy drops credentials from a proxy URL for logging.
+func redactProxy(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
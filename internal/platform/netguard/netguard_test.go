@@ -0,0 +1,225 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serverPort returns the port a test server listens on.
+func serverPort(t *testing.T, srv *httptest.Server) int {
+	t.Helper()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	return port
+}
+
+// loopbackGuard treats 127.0.0.1 as public so tests can reach local
+// listeners, while denyCIDRs stand in for private networks.
+func loopbackGuard(t *testing.T, port int, denyCIDRs ...string) *Guard {
+	t.Helper()
+	deny := []netip.Prefix{}
+	for _, cidr := range denyCIDRs {
+		deny = append(deny, netip.MustParsePrefix(cidr))
+	}
+	return NewGuard(Options{Deny: deny, Ports: []int{port}, MaxBodyBytes: 1024})
+}
+
+func TestDefaultGuardBlocksPrivateDials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback listener")
+	}))
+	defer srv.Close()
+	port := serverPort(t, srv)
+	g := NewGuard(Options{Ports: []int{port, 80}})
+
+	// The dialer hook refuses the listener's address after resolution.
+	d := &net.Dialer{Timeout: time.Second, Control: g.Control}
+	if _, err := d.Dial("tcp", srv.Listener.Addr().String()); !errors.Is(err, ErrBlocked) {
+		t.Errorf("dial loopback listener: err = %v, want ErrBlocked", err)
+	}
+	// So does the client, before dialing.
+	if _, err := g.Client(time.Second).Get(srv.URL); !errors.Is(err, ErrBlocked) {
+		t.Errorf("GET %s: err = %v, want ErrBlocked", srv.URL, err)
+	}
+
+	for _, addr := range []string{
+		"127.0.0.1:80", "127.8.9.10:80", "[::1]:80",
+		"10.1.2.3:80", "172.16.0.1:80", "172.31.255.255:80", "192.168.1.1:80",
+		"169.254.169.254:80", "[fe80::1]:80", "[fd00::1]:80",
+		"100.64.0.1:80", "0.0.0.0:80", "[::ffff:127.0.0.1]:80",
+	} {
+		if err := g.Control("tcp", addr, nil); !errors.Is(err, ErrBlocked) {
+			t.Errorf("Control(%s) = %v, want ErrBlocked", addr, err)
+		}
+	}
+	for _, addr := range []string{"93.184.216.34:80", "[2606:2800:220:1::]:80", "172.32.0.1:80"} {
+		if err := g.Control("tcp", addr, nil); err != nil {
+			t.Errorf("Control(%s) = %v, want nil", addr, err)
+		}
+	}
+	for _, raw := range []string{"http://localhost/", "http://api.localhost/", "http://127.0.0.1/", "http://[::1]/", "http://169.254.169.254/latest/meta-data/"} {
+		if err := g.CheckRawURL(raw); !errors.Is(err, ErrBlocked) {
+			t.Errorf("CheckRawURL(%s) = %v, want ErrBlocked", raw, err)
+		}
+	}
+}
+
+func TestRedirectToPrivateRejected(t *testing.T) {
+	// 127.0.0.2 plays the private network.
+	ln, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("no 127.0.0.2 listener: %v", err)
+	}
+	var privateHits atomic.Int32
+	private := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		privateHits.Add(1)
+		io.WriteString(w, "secret")
+	}))
+	private.Listener.Close()
+	private.Listener = ln
+	private.Start()
+	defer private.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/to-private":
+			http.Redirect(w, r, private.URL+"/", http.StatusFound)
+		case "/to-localhost":
+			http.Redirect(w, r, "http://localhost:"+strconv.Itoa(serverPort(t, private))+"/", http.StatusFound)
+		case "/to-public":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	defer public.Close()
+
+	g := NewGuard(Options{
+		Deny:  []netip.Prefix{netip.MustParsePrefix("127.0.0.2/32")},
+		Ports: []int{serverPort(t, public), serverPort(t, private)},
+	})
+	client := g.Client(2 * time.Second)
+
+	for _, path := range []string{"/to-private", "/to-localhost"} {
+		if _, err := client.Get(public.URL + path); !errors.Is(err, ErrBlocked) {
+			t.Errorf("GET %s: err = %v, want ErrBlocked", path, err)
+		}
+	}
+	if n := privateHits.Load(); n != 0 {
+		t.Errorf("private server got %d requests", n)
+	}
+
+	resp, err := client.Get(public.URL + "/to-public")
+	if err != nil {
+		t.Fatalf("public redirect: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestPortAndSchemeAllowlists(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	port := serverPort(t, srv)
+
+	allowed := loopbackGuard(t, port, "10.0.0.0/8")
+	resp, err := allowed.Client(time.Second).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("allowed port: %v", err)
+	}
+	resp.Body.Close()
+
+	other := loopbackGuard(t, port+1, "10.0.0.0/8")
+	if _, err := other.Client(time.Second).Get(srv.URL); !errors.Is(err, ErrBlocked) {
+		t.Errorf("port %d not allowed: err = %v, want ErrBlocked", port, err)
+	}
+	d := &net.Dialer{Timeout: time.Second, Control: other.Control}
+	if _, err := d.Dial("tcp", srv.Listener.Addr().String()); !errors.Is(err, ErrBlocked) {
+		t.Errorf("dial port %d not allowed: err = %v, want ErrBlocked", port, err)
+	}
+
+	defaults := NewGuard(Options{})
+	for raw, want := range map[string]bool{
+		"http://example.com/":       true,
+		"https://example.com:8443/": true,
+		"http://example.com:22/":    false,
+		"https://example.com:6379/": false,
+		"ftp://example.com/":        false,
+		"file:///etc/passwd":        false,
+		"gopher://example.com:70/":  false,
+		"javascript:alert(1)":       false,
+	} {
+		err := defaults.CheckRawURL(raw)
+		if want && err != nil {
+			t.Errorf("CheckRawURL(%s) = %v, want nil", raw, err)
+		}
+		if !want && !errors.Is(err, ErrBlocked) {
+			t.Errorf("CheckRawURL(%s) = %v, want ErrBlocked", raw, err)
+		}
+	}
+}
+
+func TestResponseSizeCap(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		body := strings.Repeat("x", n)
+		if r.URL.Query().Get("chunked") == "true" {
+			// No Content-Length: the cap must apply while reading.
+			io.WriteString(w, body[:n/2])
+			w.(http.Flusher).Flush()
+			io.WriteString(w, body[n/2:])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(n))
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	g := loopbackGuard(t, serverPort(t, srv), "10.0.0.0/8") // 1 KiB cap
+	client := g.Client(2 * time.Second)
+
+	get := func(ctx context.Context, query string) ([]byte, error) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/?"+query, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	}
+
+	ctx := context.Background()
+	for _, query := range []string{"n=1024", "n=1024&chunked=true", "n=10"} {
+		if _, err := get(ctx, query); err != nil {
+			t.Errorf("%s: %v", query, err)
+		}
+	}
+	for _, query := range []string{"n=1025", "n=4096&chunked=true"} {
+		if _, err := get(ctx, query); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: err = %v, want ErrTooLarge", query, err)
+		}
+	}
+
+	// A per-request cap overrides the guard's.
+	larger := WithMaxBodyBytes(ctx, 8192)
+	if _, err := get(larger, "n=4096&chunked=true"); err != nil {
+		t.Errorf("raised cap: %v", err)
+	}
+	if _, err := get(WithMaxBodyBytes(ctx, 100), "n=101"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("lowered cap: err = %v, want ErrTooLarge", err)
+	}
+}
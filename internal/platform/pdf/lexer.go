@@ -0,0 +1,287 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// PDF object model, as far as this package needs it.
+type (
+	name    string
+	keyword string
+	str     []byte
+	ref     struct{ num, gen int }
+	dict    map[name]any
+	array   []any
+)
+
+var errSyntax = errors.New("pdf: syntax error")
+
+// maxNesting bounds how deeply dictionaries and arrays may nest. Real files
+// stay in single digits; the limit keeps a file of nothing but "[" from
+// overflowing the stack, which no recover can catch.
+const maxNesting = 100
+
+// lexer reads PDF tokens and objects from a byte slice. It is used for file
+// bodies, object streams and page content streams alike.
+type lexer struct {
+	b     []byte
+	pos   int
+	depth int // open dictionaries and arrays in object
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		if isSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.b) && l.b[l.pos] != '\n' && l.b[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// token returns the next token: a number (int64 or float64), name, str,
+// keyword, or one of the delimiters "<<", ">>", "[" and "]" as keywords.
+func (l *lexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.b) {
+		return nil, errSyntax
+	}
+	c := l.b[l.pos]
+	switch {
+	case c == '/':
+		return l.name(), nil
+	case c == '(':
+		return l.literal(), nil
+	case c == '<':
+		if l.pos+1 < len(l.b) && l.b[l.pos+1] == '<' {
+			l.pos += 2
+			return keyword("<<"), nil
+		}
+		return l.hex(), nil
+	case c == '>':
+		if l.pos+1 < len(l.b) && l.b[l.pos+1] == '>' {
+			l.pos += 2
+			return keyword(">>"), nil
+		}
+		l.pos++
+		return nil, errSyntax
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return keyword([]byte{c}), nil
+	case c == ')':
+		l.pos++
+		return nil, errSyntax
+	}
+
+	start := l.pos
+	for l.pos < len(l.b) && !isSpace(l.b[l.pos]) && !isDelim(l.b[l.pos]) {
+		l.pos++
+	}
+	word := string(l.b[start:l.pos])
+	if n, err := strconv.ParseInt(word, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil {
+		return f, nil
+	}
+	return keyword(word), nil
+}
+
+func (l *lexer) name() name {
+	l.pos++ // '/'
+	var buf []byte
+	for l.pos < len(l.b) && !isSpace(l.b[l.pos]) && !isDelim(l.b[l.pos]) {
+		c := l.b[l.pos]
+		if c == '#' && l.pos+2 < len(l.b) {
+			if v, err := strconv.ParseUint(string(l.b[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				buf = append(buf, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		buf = append(buf, c)
+		l.pos++
+	}
+	return name(buf)
+}
+
+func (l *lexer) literal() str {
+	l.pos++ // '('
+	var buf []byte
+	depth := 1
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return buf
+			}
+		case '\\':
+			if l.pos >= len(l.b) {
+				return buf
+			}
+			e := l.b[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.b) && l.b[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.b) && l.b[l.pos] >= '0' && l.b[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.b[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+func (l *lexer) hex() str {
+	l.pos++ // '<'
+	var digits []byte
+	for l.pos < len(l.b) && l.b[l.pos] != '>' {
+		if c := l.b[l.pos]; !isSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	if l.pos < len(l.b) {
+		l.pos++ // '>'
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	buf := make([]byte, 0, len(digits)/2)
+	for i := 0; i+1 < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			break
+		}
+		buf = append(buf, byte(v))
+	}
+	return buf
+}
+
+// object reads a complete object, resolving "n g R" into a ref. Keywords
+// other than true, false and null are returned as is, so content streams can
+// be read with the same function.
+func (l *lexer) object() (any, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case keyword:
+		if t == "<<" || t == "[" {
+			if l.depth >= maxNesting {
+				return nil, errSyntax
+			}
+			l.depth++
+			defer func() { l.depth-- }()
+		}
+		switch t {
+		case "<<":
+			d := dict{}
+			for {
+				l.skipSpace()
+				if bytes.HasPrefix(l.b[l.pos:], []byte(">>")) {
+					l.pos += 2
+					return d, nil
+				}
+				key, err := l.token()
+				if err != nil {
+					return d, err
+				}
+				k, ok := key.(name)
+				if !ok {
+					return d, errSyntax
+				}
+				v, err := l.object()
+				if err != nil {
+					return d, err
+				}
+				d[k] = v
+			}
+		case "[":
+			var a array
+			for {
+				l.skipSpace()
+				if l.pos < len(l.b) && l.b[l.pos] == ']' {
+					l.pos++
+					return a, nil
+				}
+				v, err := l.object()
+				if err != nil {
+					return a, err
+				}
+				a = append(a, v)
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t, nil
+	case int64:
+		// Look ahead for "gen R".
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(int64); ok {
+				if r, err := l.token(); err == nil && r == keyword("R") {
+					return ref{num: int(t), gen: int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return tok, nil
+}
@@ -0,0 +1,423 @@
+// Package pdf reads the metadata of PDF files: the document information
+// dictionary (or XMP), the page count and the text of the first page. It is
+// deliberately forgiving: objects are found by scanning the file rather than
+// trusting the cross-reference table, so truncated and slightly broken files
+// still yield whatever they contain. Only what link metadata needs is
+// implemented; there is no rendering, encryption or font metrics support.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrNotPDF is returned for data without a PDF header.
+var ErrNotPDF = errors.New("pdf: not a PDF file")
+
+// ErrEncrypted is returned for encrypted files, whose strings and streams
+// can't be read without decrypting.
+var ErrEncrypted = errors.New("pdf: file is encrypted")
+
+// MaxTextLength caps FirstPageText, in bytes.
+const MaxTextLength = 20_000
+
+// Info is what Parse learns about a document.
+type Info struct {
+	Title         string
+	Author        string
+	Subject       string
+	Keywords      string
+	Created       *time.Time
+	Pages         int
+	FirstPageText string
+}
+
+// IsPDF reports whether data starts like a PDF file. Some generators put junk
+// before the header, so the first kilobyte is searched.
+func IsPDF(data []byte) bool {
+	return bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-"))
+}
+
+type object struct {
+	value  any
+	stream []byte // raw, still encoded
+}
+
+type document struct {
+	objects map[int]object
+	trailer dict
+}
+
+// Parse reads a PDF file.
+func Parse(data []byte) (Info, error) {
+	if !IsPDF(data) {
+		return Info{}, ErrNotPDF
+	}
+	doc := &document{objects: map[int]object{}, trailer: dict{}}
+	doc.scan(data)
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return Info{}, ErrEncrypted
+	}
+
+	var info Info
+	if d, ok := doc.resolve(doc.trailer["Info"]).(dict); ok {
+		info.Title = doc.text(d["Title"])
+		info.Author = doc.text(d["Author"])
+		info.Subject = doc.text(d["Subject"])
+		info.Keywords = doc.text(d["Keywords"])
+		info.Created = parseDate(doc.text(d["CreationDate"]))
+	}
+
+	root, _ := doc.resolve(doc.trailer["Root"]).(dict)
+	if root == nil {
+		root = doc.findType("Catalog")
+	}
+	if root != nil {
+		if meta := doc.streamData(root["Metadata"]); len(meta) > 0 {
+			xmpTitle, xmpAuthor := parseXMP(meta)
+			info.Title = firstNonEmpty(info.Title, xmpTitle)
+			info.Author = firstNonEmpty(info.Author, xmpAuthor)
+		}
+		if pages, ok := doc.resolve(root["Pages"]).(dict); ok {
+			if n, ok := doc.resolve(pages["Count"]).(int64); ok {
+				info.Pages = int(n)
+			}
+			if page := doc.firstPage(pages); page != nil {
+				info.FirstPageText = doc.pageText(page)
+			}
+		}
+	}
+	if info.Pages == 0 {
+		for _, obj := range doc.objects {
+			if d, ok := obj.value.(dict); ok && d["Type"] == name("Page") {
+				info.Pages++
+			}
+		}
+	}
+	return info, nil
+}
+
+var objHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+var trailerRe = regexp.MustCompile(`trailer\s*<<`)
+
+// scan collects every "n g obj ... endobj" in file order, so objects from
+// incremental updates replace earlier versions, then unpacks object streams
+// and merges the trailers.
+func (doc *document) scan(data []byte) {
+	var objStreams []object
+	pos := 0
+	for pos < len(data) {
+		loc := objHeaderRe.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num := atoi(data[pos+loc[2] : pos+loc[3]])
+		l := &lexer{b: data, pos: pos + loc[1]}
+		value, err := l.object()
+		if err != nil {
+			pos += loc[1]
+			continue
+		}
+		obj := object{value: value}
+		if d, ok := value.(dict); ok {
+			if stream, end := streamAt(data, l.pos, d); end > 0 {
+				obj.stream = stream
+				l.pos = end
+			}
+			if d["Type"] == name("ObjStm") {
+				objStreams = append(objStreams, obj)
+			}
+			if d["Type"] == name("XRef") {
+				doc.mergeTrailer(d)
+			}
+		}
+		doc.objects[num] = obj
+		pos = l.pos
+	}
+
+	for _, obj := range objStreams {
+		doc.unpackObjStm(obj)
+	}
+	for _, loc := range trailerRe.FindAllIndex(data, -1) {
+		l := &lexer{b: data, pos: loc[1] - 2}
+		if v, err := l.object(); err == nil {
+			if d, ok := v.(dict); ok {
+				doc.mergeTrailer(d)
+			}
+		}
+	}
+}
+
+// mergeTrailer keeps the latest value of each trailer entry we use.
+func (doc *document) mergeTrailer(d dict) {
+	for _, key := range []name{"Root", "Info", "Encrypt"} {
+		if v, ok := d[key]; ok {
+			doc.trailer[key] = v
+		}
+	}
+}
+
+// streamAt returns the raw stream following a dictionary that ends at pos,
+// and the offset just past "endstream", or end 0 when there is no stream.
+func streamAt(data []byte, pos int, d dict) ([]byte, int) {
+	l := &lexer{b: data, pos: pos}
+	l.skipSpace()
+	if !bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+		return nil, 0
+	}
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+	if n, ok := d["Length"].(int64); ok && n >= 0 && start+int(n) <= len(data) {
+		end := start + int(n)
+		rest := data[end:min(len(data), end+32)]
+		if i := bytes.Index(rest, []byte("endstream")); i >= 0 {
+			return data[start:end], end + i + len("endstream")
+		}
+	}
+	// Indirect or wrong /Length: fall back to searching for the keyword.
+	i := bytes.Index(data[start:], []byte("endstream"))
+	if i < 0 {
+		return data[start:], len(data)
+	}
+	stream := bytes.TrimRight(data[start:start+i], "\r\n")
+	return stream, start + i + len("endstream")
+}
+
+// unpackObjStm adds the objects compressed into an object stream. Objects
+// already defined directly in the file win.
+func (doc *document) unpackObjStm(obj object) {
+	d := obj.value.(dict)
+	data := doc.decode(d, obj.stream)
+	n, _ := d["N"].(int64)
+	first, _ := d["First"].(int64)
+	if data == nil || n <= 0 || int(first) > len(data) {
+		return
+	}
+	l := &lexer{b: data}
+	type entry struct{ num, offset int }
+	var entries []entry
+	for i := 0; i < int(n); i++ {
+		num, err1 := l.token()
+		off, err2 := l.token()
+		a, ok1 := num.(int64)
+		b, ok2 := off.(int64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 {
+			break
+		}
+		entries = append(entries, entry{int(a), int(b)})
+	}
+	for _, e := range entries {
+		if _, exists := doc.objects[e.num]; exists {
+			continue
+		}
+		l := &lexer{b: data, pos: int(first) + e.offset}
+		if l.pos >= len(data) {
+			continue
+		}
+		if value, err := l.object(); err == nil {
+			doc.objects[e.num] = object{value: value}
+		}
+	}
+}
+
+// resolve follows references.
+func (doc *document) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		r, ok := v.(ref)
+		if !ok {
+			return v
+		}
+		v = doc.objects[r.num].value
+	}
+	return nil
+}
+
+// streamData returns the decoded stream a reference points at.
+func (doc *document) streamData(v any) []byte {
+	r, ok := v.(ref)
+	if !ok {
+		return nil
+	}
+	obj := doc.objects[r.num]
+	d, ok := obj.value.(dict)
+	if !ok || obj.stream == nil {
+		return nil
+	}
+	return doc.decode(d, obj.stream)
+}
+
+// decode applies a stream's filters. Only FlateDecode and ASCIIHexDecode are
+// supported; anything else (images, mostly) returns nil. A damaged Flate
+// stream yields what could be inflated.
+func (doc *document) decode(d dict, raw []byte) []byte {
+	var filters []any
+	switch f := doc.resolve(d["Filter"]).(type) {
+	case name:
+		filters = []any{f}
+	case array:
+		filters = f
+	}
+	data := raw
+	for _, f := range filters {
+		switch doc.resolve(f) {
+		case name("FlateDecode"), name("Fl"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil
+			}
+			out, _ := io.ReadAll(io.LimitReader(zr, 64<<20))
+			data = out
+		case name("ASCIIHexDecode"), name("AHx"):
+			l := &lexer{b: append(append([]byte("<"), data...), '>')}
+			data = l.hex()
+		default:
+			return nil
+		}
+	}
+	return data
+}
+
+func (doc *document) findType(t name) dict {
+	for _, obj := range doc.objects {
+		if d, ok := obj.value.(dict); ok && d["Type"] == t {
+			return d
+		}
+	}
+	return nil
+}
+
+// firstPage descends the page tree along first kids, carrying inherited
+// resources down.
+func (doc *document) firstPage(node dict) dict {
+	var resources any
+	for depth := 0; depth < 32 && node != nil; depth++ {
+		if r, ok := node["Resources"]; ok {
+			resources = r
+		}
+		if node["Type"] != name("Pages") {
+			if _, ok := node["Resources"]; !ok && resources != nil {
+				node["Resources"] = resources
+			}
+			return node
+		}
+		kids, _ := doc.resolve(node["Kids"]).(array)
+		if len(kids) == 0 {
+			return nil
+		}
+		node, _ = doc.resolve(kids[0]).(dict)
+	}
+	return nil
+}
+
+// text decodes a PDF text string: UTF-16BE or UTF-8 with a byte order mark,
+// otherwise PDFDocEncoding, read as Latin-1.
+func (doc *document) text(v any) string {
+	s, ok := doc.resolve(v).(str)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(decodeTextString(s), "\x00", ""))
+}
+
+func decodeTextString(s []byte) string {
+	switch {
+	case len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff:
+		return utf16BE(s[2:])
+	case len(s) >= 3 && s[0] == 0xef && s[1] == 0xbb && s[2] == 0xbf:
+		return string(s[3:])
+	}
+	runes := make([]rune, len(s))
+	for i, c := range s {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func utf16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+var pdfDateRe = regexp.MustCompile(`^(?:D:)?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?`)
+
+// parseDate parses "D:YYYYMMDDHHmmSS" dates, ignoring the time zone suffix.
+func parseDate(s string) *time.Time {
+	m := pdfDateRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil
+	}
+	parts := [6]int{0, 1, 1, 0, 0, 0}
+	for i := 1; i <= 6; i++ {
+		if m[i] != "" {
+			parts[i-1] = atoi([]byte(m[i]))
+		}
+	}
+	if parts[0] < 1900 || parts[1] < 1 || parts[1] > 12 || parts[2] < 1 || parts[2] > 31 {
+		return nil
+	}
+	t := time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, time.UTC)
+	return &t
+}
+
+var (
+	xmpTitleRe   = regexp.MustCompile(`(?s)<dc:title>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmpCreatorRe = regexp.MustCompile(`(?s)<dc:creator>(.*?)</dc:creator>`)
+	xmpLiRe      = regexp.MustCompile(`(?s)<rdf:li[^>]*>(.*?)</rdf:li>`)
+)
+
+// parseXMP pulls dc:title and dc:creator out of an XMP packet.
+func parseXMP(data []byte) (title, author string) {
+	if m := xmpTitleRe.FindSubmatch(data); m != nil {
+		title = xmlText(m[1])
+	}
+	if m := xmpCreatorRe.FindSubmatch(data); m != nil {
+		var names []string
+		for _, li := range xmpLiRe.FindAllSubmatch(m[1], -1) {
+			if n := xmlText(li[1]); n != "" {
+				names = append(names, n)
+			}
+		}
+		author = strings.Join(names, ", ")
+	}
+	return title, author
+}
+
+var xmlEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+
+func xmlText(b []byte) string {
+	return strings.TrimSpace(xmlEntities.Replace(string(b)))
+}
+
+func atoi(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// build assembles a PDF from numbered object bodies and a trailer. Streams
+// are given as "<<dict>>\nstream\n...\nendstream" bodies by the caller.
+func build(objects []string, trailer string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, body := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	b.WriteString("trailer\n" + trailer + "\n%%EOF\n")
+	return b.Bytes()
+}
+
+func stream(dict, content string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(content), content)
+}
+
+func flate(s string) string {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write([]byte(s))
+	zw.Close()
+	return b.String()
+}
+
+func simplePDF(content string) []byte {
+	return build([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		stream("", content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Title (Deep Learning \\(2nd ed.\\)) /Author <FEFF004A0061006E0065> /CreationDate (D:20230102030405Z) >>",
+	}, "<< /Root 1 0 R /Info 6 0 R /Size 7 >>")
+}
+
+func TestParse(t *testing.T) {
+	info, err := Parse(simplePDF("BT /F1 12 Tf 72 700 Td (Hello) Tj [(Wor) -20 (ld)] TJ 0 -14 Td (Second line) Tj ET"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Deep Learning (2nd ed.)" {
+		t.Errorf("Title = %q", info.Title)
+	}
+	if info.Author != "Jane" {
+		t.Errorf("Author = %q", info.Author)
+	}
+	if info.Created == nil || info.Created.Format("2006-01-02T15:04:05Z07:00") != "2023-01-02T03:04:05Z" {
+		t.Errorf("Created = %v", info.Created)
+	}
+	if info.Pages != 1 {
+		t.Errorf("Pages = %d", info.Pages)
+	}
+	if want := "HelloWorld\nSecond line"; info.FirstPageText != want {
+		t.Errorf("FirstPageText = %q, want %q", info.FirstPageText, want)
+	}
+}
+
+func TestParseObjectStream(t *testing.T) {
+	// Catalog and page tree compressed into an object stream, as PDF 1.5
+	// writers do, with a cross-reference stream instead of a trailer.
+	bodies := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 4 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+	}
+	var header, body string
+	for i, b := range bodies {
+		header += fmt.Sprintf("%d %d ", i+1, len(body))
+		body += b + " "
+	}
+	objStm := fmt.Sprintf("/Type /ObjStm /N %d /First %d /Filter /FlateDecode", len(bodies), len(header))
+	data := []byte("%PDF-1.5\n" +
+		"4 0 obj\n" + stream(objStm, flate(header+body)) + "\nendobj\n" +
+		"5 0 obj\n" + stream("", "BT (Compressed) Tj ET") + "\nendobj\n" +
+		"6 0 obj\n" + stream("/Type /XRef /Root 1 0 R /Size 7", "") + "\nendobj\n")
+	info, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Pages != 4 || info.FirstPageText != "Compressed" {
+		t.Errorf("got Pages %d, FirstPageText %q", info.Pages, info.FirstPageText)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse([]byte("<html></html>")); !errors.Is(err, ErrNotPDF) {
+		t.Errorf("html: err = %v, want ErrNotPDF", err)
+	}
+	encrypted := build([]string{"<< /Filter /Standard /V 2 >>"}, "<< /Encrypt 1 0 R >>")
+	if _, err := Parse(encrypted); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("encrypted: err = %v, want ErrEncrypted", err)
+	}
+}
+
+// Hostile nesting must fail the object, not overflow the stack.
+func TestParseDeepNesting(t *testing.T) {
+	tests := map[string][]byte{
+		"arrays":       []byte("%PDF-1.4\n1 0 obj\n" + strings.Repeat("[", 15<<20)),
+		"dicts":        []byte("%PDF-1.4\n1 0 obj\n" + strings.Repeat("<< /A ", 1<<20)),
+		"trailer":      []byte("%PDF-1.4\ntrailer\n<<" + strings.Repeat(" /A [", 1<<20)),
+		"page content": simplePDF("BT " + strings.Repeat("[", 1<<20) + " ET"),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			Parse(data)
+		})
+	}
+}
+
+func TestLexerNestingLimit(t *testing.T) {
+	ok := strings.Repeat("[", maxNesting) + strings.Repeat("]", maxNesting)
+	if _, err := (&lexer{b: []byte(ok)}).object(); err != nil {
+		t.Errorf("%d levels: %v", maxNesting, err)
+	}
+	tooDeep := "[" + ok + "]"
+	if _, err := (&lexer{b: []byte(tooDeep)}).object(); !errors.Is(err, errSyntax) {
+		t.Errorf("%d levels: err = %v, want errSyntax", maxNesting+1, err)
+	}
+	// The depth unwinds, so siblings after a deep value still parse.
+	l := &lexer{b: []byte(ok + " " + ok)}
+	for i := 0; i < 2; i++ {
+		if _, err := l.object(); err != nil {
+			t.Fatalf("object %d: %v", i, err)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(simplePDF("BT /F1 12 Tf (Hello) Tj ET"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj\n[[[[<< /A [1 0 R] >>]]]]\nendobj\ntrailer << /Root 1 0 R >>"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj\n<< /Length 5 /Filter /ASCIIHexDecode >>\nstream\n48656C\nendstream\nendobj\n"))
+	f.Add([]byte("%PDF-1.7\n1 0 obj\n(unterminated \\\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(data)
+	})
+}
@@ -0,0 +1,302 @@
+package pdf
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// font decodes string operands of text operators to Unicode.
+type font struct {
+	cmap    map[string]string // code bytes -> text, from /ToUnicode
+	lengths []int             // code lengths present in cmap, longest first
+	twoByte bool              // Type0 font without a usable cmap
+}
+
+func (f *font) decode(s []byte) string {
+	if f == nil || len(f.cmap) == 0 {
+		if f != nil && f.twoByte {
+			// CIDs without a mapping can't be turned into text.
+			return ""
+		}
+		return latin1(s)
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		matched := false
+		for _, n := range f.lengths {
+			if i+n <= len(s) {
+				if t, ok := f.cmap[string(s[i:i+n])]; ok {
+					b.WriteString(t)
+					i += n
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			i += f.lengths[len(f.lengths)-1]
+		}
+	}
+	return b.String()
+}
+
+func latin1(s []byte) string {
+	runes := make([]rune, 0, len(s))
+	for _, c := range s {
+		if c >= 0x20 || c == '\t' {
+			runes = append(runes, rune(c))
+		}
+	}
+	return string(runes)
+}
+
+// fonts loads the fonts named in a page's resources.
+func (doc *document) fonts(page dict) map[name]*font {
+	out := map[name]*font{}
+	resources, _ := doc.resolve(page["Resources"]).(dict)
+	fontDict, _ := doc.resolve(resources["Font"]).(dict)
+	for key, v := range fontDict {
+		fd, ok := doc.resolve(v).(dict)
+		if !ok {
+			continue
+		}
+		f := &font{twoByte: fd["Subtype"] == name("Type0")}
+		if data := doc.streamData(fd["ToUnicode"]); data != nil {
+			f.cmap, f.lengths = parseCMap(data)
+		}
+		out[key] = f
+	}
+	return out
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap.
+func parseCMap(data []byte) (map[string]string, []int) {
+	cmap := map[string]string{}
+	lengthSet := map[int]bool{}
+	l := &lexer{b: data}
+	var stack []any
+	for {
+		tok, err := l.object()
+		if err != nil {
+			break
+		}
+		kw, ok := tok.(keyword)
+		if !ok {
+			stack = append(stack, tok)
+			continue
+		}
+		switch kw {
+		case "endbfchar":
+			for i := 0; i+1 < len(stack); i += 2 {
+				src, ok1 := stack[i].(str)
+				dst, ok2 := stack[i+1].(str)
+				if ok1 && ok2 && len(src) > 0 {
+					cmap[string(src)] = utf16BE(dst)
+					lengthSet[len(src)] = true
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(stack); i += 3 {
+				lo, ok1 := stack[i].(str)
+				hi, ok2 := stack[i+1].(str)
+				if !ok1 || !ok2 || len(lo) == 0 || len(lo) != len(hi) || len(lo) > 4 {
+					continue
+				}
+				lengthSet[len(lo)] = true
+				start, end := beUint(lo), beUint(hi)
+				if end < start || end-start > 0xffff {
+					continue
+				}
+				switch dst := stack[i+2].(type) {
+				case str:
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for c := start; c <= end; c++ {
+						r := append([]rune(nil), base...)
+						r[len(r)-1] += rune(c - start)
+						cmap[string(beBytes(c, len(lo)))] = string(r)
+					}
+				case array:
+					for j, d := range dst {
+						if s, ok := d.(str); ok && start+uint32(j) <= end {
+							cmap[string(beBytes(start+uint32(j), len(lo)))] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		stack = stack[:0]
+	}
+	var lengths []int
+	for n := 4; n >= 1; n-- {
+		if lengthSet[n] {
+			lengths = append(lengths, n)
+		}
+	}
+	return cmap, lengths
+}
+
+func beUint(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func beBytes(v uint32, n int) []byte {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+// pageText extracts the text shown on a page, in content stream order, with
+// line breaks where the text position moves down.
+func (doc *document) pageText(page dict) string {
+	var content []byte
+	switch c := doc.resolve(page["Contents"]).(type) {
+	case array:
+		for _, part := range c {
+			content = append(content, doc.streamData(part)...)
+			content = append(content, '\n')
+		}
+	default:
+		content = doc.streamData(page["Contents"])
+	}
+	if len(content) == 0 {
+		return ""
+	}
+
+	fonts := doc.fonts(page)
+	var current *font
+	var out strings.Builder
+	newline := func() {
+		if s := out.String(); len(s) > 0 && !strings.HasSuffix(s, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		if s := out.String(); len(s) > 0 && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			out.WriteByte(' ')
+		}
+	}
+
+	// A content stream nested past maxNesting ends extraction like any other
+	// syntax error.
+	l := &lexer{b: content}
+	var operands []any
+	lineY := math.NaN()
+	for out.Len() < MaxTextLength {
+		tok, err := l.object()
+		if err != nil {
+			break
+		}
+		op, ok := tok.(keyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				if n, ok := operands[len(operands)-2].(name); ok {
+					current = fonts[n]
+				}
+			}
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				newline()
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(str); ok {
+					out.WriteString(current.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				if parts, ok := operands[len(operands)-1].(array); ok {
+					for _, p := range parts {
+						switch v := p.(type) {
+						case str:
+							out.WriteString(current.decode(v))
+						case int64:
+							// Large negative adjustments are word gaps.
+							if v < -200 {
+								space()
+							}
+						case float64:
+							if v < -200 {
+								space()
+							}
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty := number(operands[len(operands)-1]); math.Abs(ty) > 0.1 {
+					newline()
+				} else if number(operands[len(operands)-2]) > 0 {
+					space()
+				}
+			}
+		case "T*":
+			newline()
+		case "Tm":
+			// Generators that place every word with Tm keep y on a line.
+			if len(operands) >= 6 {
+				y := number(operands[5])
+				if math.IsNaN(lineY) || math.Abs(y-lineY) > 0.1 {
+					newline()
+				} else {
+					space()
+				}
+				lineY = y
+			} else {
+				newline()
+			}
+		case "BI":
+			// Skip inline image data up to "EI".
+			if i := bytes.Index(l.b[l.pos:], []byte("EI")); i >= 0 {
+				l.pos += i + 2
+			} else {
+				l.pos = len(l.b)
+			}
+		}
+		operands = operands[:0]
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	text := strings.Join(kept, "\n")
+	if len(text) > MaxTextLength {
+		cut := MaxTextLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return text
+}
+
+func number(v any) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,198 @@
+// Package readability pulls the main body text out of an HTML page, dropping
+// navigation, sidebars, comments and other chrome. It follows the approach of
+// Mozilla's Readability: paragraphs vote for their ancestors, and the
+// best-scoring container, discounted by how much of it is link text, is taken
+// as the content.
+package readability
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// MaxTextLength caps the extracted text, in bytes. Postgres tsvectors are
+// limited to 1 MB and nothing past this is useful for search.
+const MaxTextLength = 100_000
+
+// WordsPerMinute is the reading speed used for reading-time estimates.
+const WordsPerMinute = 230
+
+var (
+	unlikelyRe = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|nav|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|popup|promo|ad-break|advert|agegate|pagination|pager`)
+	maybeRe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow|story|entry|post`)
+	positiveRe = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeRe = regexp.MustCompile(`(?i)hidden|banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// junk is removed before scoring.
+const junk = `script, style, noscript, template, iframe, object, embed, svg, canvas, form, button, input, select, textarea, nav, aside, footer, header, dialog, [hidden], [aria-hidden="true"], [role="navigation"], [role="complementary"], [role="banner"], [role="contentinfo"]`
+
+// blocks are the elements whose text is kept, each as its own paragraph.
+const blocks = `p, pre, blockquote, li, h1, h2, h3, h4, h5, h6, td, dd, figcaption`
+
+// Extract returns the main text of a page: paragraphs separated by blank
+// lines, whitespace collapsed, truncated to MaxTextLength. It returns "" when
+// no block of prose is found. doc is not modified.
+func Extract(doc *goquery.Selection) string {
+	body := doc.Find(`body`).First()
+	if body.Length() == 0 {
+		body = doc
+	}
+	root := body.Clone()
+	root.Find(junk).Remove()
+	root.Find(`*`).Each(func(_ int, s *goquery.Selection) {
+		if s.Is(`body, article, main`) {
+			return
+		}
+		id, _ := s.Attr("id")
+		class, _ := s.Attr("class")
+		role, _ := s.Attr("role")
+		match := class + " " + id + " " + role
+		if unlikelyRe.MatchString(match) && !maybeRe.MatchString(match) {
+			s.Remove()
+		}
+	})
+
+	best := topCandidate(root)
+	if best == nil {
+		return ""
+	}
+	return collect(best)
+}
+
+// topCandidate scores the ancestors of every paragraph-like block and returns
+// the best, or nil when the page has no prose.
+func topCandidate(root *goquery.Selection) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var order []*goquery.Selection
+	init := func(s *goquery.Selection) {
+		n := s.Get(0)
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(s)
+			order = append(order, s)
+		}
+	}
+
+	root.Find(`p, pre, td, blockquote`).Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if utf8.RuneCountInString(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		for level, ancestor := 0, p.Parent(); level < 3 && ancestor.Length() > 0; level, ancestor = level+1, ancestor.Parent() {
+			if ancestor.Is(`html`) {
+				break
+			}
+			init(ancestor)
+			// Parent gets the full score, grandparent half, then a third.
+			scores[ancestor.Get(0)] += score / float64(level+1)
+		}
+	})
+
+	var best *goquery.Selection
+	bestScore := 0.0
+	for _, s := range order {
+		score := scores[s.Get(0)] * (1 - linkDensity(s))
+		if score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	return best
+}
+
+func classWeight(s *goquery.Selection) float64 {
+	weight := 0.0
+	for _, attr := range []string{"class", "id"} {
+		v, _ := s.Attr(attr)
+		if v == "" {
+			continue
+		}
+		if negativeRe.MatchString(v) {
+			weight -= 25
+		}
+		if positiveRe.MatchString(v) {
+			weight += 25
+		}
+	}
+	if s.Is(`article, main, [itemprop="articleBody"]`) {
+		weight += 25
+	}
+	return weight
+}
+
+func linkDensity(s *goquery.Selection) float64 {
+	total := len(strings.TrimSpace(s.Text()))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find(`a`).Each(func(_ int, a *goquery.Selection) {
+		links += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(links) / float64(total)
+}
+
+// collect joins the text of the content's blocks, skipping link lists and
+// blocks nested inside blocks already taken.
+func collect(content *goquery.Selection) string {
+	var b strings.Builder
+	content.Find(blocks).Each(func(_ int, s *goquery.Selection) {
+		if b.Len() >= MaxTextLength {
+			return
+		}
+		// The enclosing block's text already includes this one.
+		if s.ParentsUntilSelection(content).Filter(blocks).Length() > 0 {
+			return
+		}
+		text := normalize(s.Text())
+		if text == "" || (linkDensity(s) > 0.5 && !s.Is(`h1, h2, h3, h4, h5, h6`)) {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(text)
+	})
+	if b.Len() == 0 {
+		b.WriteString(normalize(content.Text()))
+	}
+	return truncate(b.String(), MaxTextLength)
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate cuts s to at most n bytes, at a word boundary where possible.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	if i := strings.LastIndexAny(s[:cut], " \n"); i > n/2 {
+		cut = i
+	}
+	return strings.TrimSpace(s[:cut])
+}
+
+// CountWords counts whitespace-separated words.
+func CountWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// ReadingMinutes estimates reading time at WordsPerMinute, rounded up. It is
+// 0 only when there are no words.
+func ReadingMinutes(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
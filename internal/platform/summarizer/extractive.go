@@ -0,0 +1,141 @@
+package summarizer
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ExtractiveSummarizer picks the most representative sentences from the page
+// by word frequency. It needs no model and always works offline.
+type ExtractiveSummarizer struct{}
+
+func NewExtractive() *ExtractiveSummarizer { return &ExtractiveSummarizer{} }
+
+func (s *ExtractiveSummarizer) Name() string { return "extractive" }
+
+var sentenceCounts = map[string]int{"tiny": 1, "short": 3, "medium": 6, "long": 12}
+
+var sentenceEnd = regexp.MustCompile(`([.!?])["')\]]?\s+`)
+
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a an and are as at be been but by can could did do does for from had has have he her
+		his how i if in into is it its just more most my no not of on or our out over she so some such than that the
+		their them then there these they this those to too up us very was we were what when where which who why will
+		with would you your about after all also any because before between both each few other only own same should
+		while may might must here new one two use used using get got like`) {
+		stopwords[w] = true
+	}
+}
+
+func (s *ExtractiveSummarizer) Summarize(_ context.Context, in Input) (string, string, error) {
+	sentences := splitSentences(in.Text)
+	if len(sentences) == 0 {
+		return "", "", ErrNoText
+	}
+	n, ok := sentenceCounts[in.Size]
+	if !ok {
+		n = sentenceCounts["short"]
+	}
+	picked := topSentences(sentences, n)
+
+	var b strings.Builder
+	b.WriteString("## Summary\n\n")
+	if in.Size == "medium" || in.Size == "long" {
+		lead := 2
+		if len(picked) < lead {
+			lead = len(picked)
+		}
+		b.WriteString(strings.Join(picked[:lead], " "))
+		if len(picked) > lead {
+			b.WriteString("\n\n## Key Points\n")
+			for _, sentence := range picked[lead:] {
+				b.WriteString("\n- " + sentence)
+			}
+		}
+	} else {
+		b.WriteString(strings.Join(picked, " "))
+	}
+	return b.String(), s.Name(), nil
+}
+
+func splitSentences(text string) []string {
+	text = strings.Join(strings.Fields(text), " ")
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		sentences = appendSentence(sentences, text[start:loc[1]])
+		start = loc[1]
+	}
+	sentences = appendSentence(sentences, text[start:])
+	return sentences
+}
+
+// appendSentence keeps sentences that look like prose rather than navigation
+// fragments or giant run-ons.
+func appendSentence(sentences []string, s string) []string {
+	s = strings.TrimSpace(s)
+	if len(s) < 40 || len(s) > 600 || len(strings.Fields(s)) < 6 {
+		return sentences
+	}
+	return append(sentences, s)
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// topSentences scores sentences by the average document frequency of their
+// content words, with a small bonus for appearing early, and returns the best
+// n in their original order.
+func topSentences(sentences []string, n int) []string {
+	freq := map[string]float64{}
+	for _, s := range sentences {
+		for _, w := range words(s) {
+			if !stopwords[w] && len(w) > 2 {
+				freq[w]++
+			}
+		}
+	}
+
+	type scored struct {
+		idx   int
+		score float64
+	}
+	scores := make([]scored, len(sentences))
+	for i, s := range sentences {
+		var total float64
+		var count int
+		for _, w := range words(s) {
+			if f, ok := freq[w]; ok {
+				total += f
+				count++
+			}
+		}
+		score := 0.0
+		if count > 0 {
+			score = total / math.Sqrt(float64(count)+1)
+		}
+		score *= 1 + 0.5/float64(i+1)
+		scores[i] = scored{idx: i, score: score}
+	}
+	sort.SliceStable(scores, func(a, b int) bool { return scores[a].score > scores[b].score })
+	if n > len(scores) {
+		n = len(scores)
+	}
+	scores = scores[:n]
+	sort.Slice(scores, func(a, b int) bool { return scores[a].idx < scores[b].idx })
+
+	out := make([]string, n)
+	for i, sc := range scores {
+		out[i] = sentences[sc.idx]
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package summarizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxPromptChars bounds how much page text is sent to the model.
+const maxPromptChars = 24000
+
+var sizeInstructions = map[string]string{
+	"tiny":   "Write a single sentence describing what this page is about.",
+	"short":  "Write a 2-3 sentence summary of this page.",
+	"medium": "Write a one-paragraph summary followed by 3-5 bullet points of key takeaways.",
+	"long":   "Write a detailed summary with sections for Overview, Key Points and Notable Details, using markdown headings and bullet points.",
+}
+
+// OpenAISummarizer calls an OpenAI-compatible /chat/completions endpoint.
+type OpenAISummarizer struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func NewOpenAI(baseURL, apiKey, model string) *OpenAISummarizer {
+	return &OpenAISummarizer{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+func (s *OpenAISummarizer) Name() string { return s.model }
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (s *OpenAISummarizer) Summarize(ctx context.Context, in Input) (string, string, error) {
+	text := in.Text
+	if len(text) > maxPromptChars {
+		cut := maxPromptChars
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	instruction, ok := sizeInstructions[in.Size]
+	if !ok {
+		instruction = sizeInstructions["short"]
+	}
+
+	body, err := json.Marshal(chatRequest{
+		Model: s.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: "You write concise research notes about web pages in markdown. Start with a '## Summary' heading. Do not invent facts that are not in the page."},
+			{Role: "user", Content: fmt.Sprintf("%s\n\nTitle: %s\nURL: %s\n\nPage text:\n%s", instruction, in.Title, in.URL, text)},
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("summary request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", "", fmt.Errorf("summary request failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var parsed chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", "", fmt.Errorf("invalid summary response: %w", err)
+	}
+	if len(parsed.Choices) == 0 || strings.TrimSpace(parsed.Choices[0].Message.Content) == "" {
+		return "", "", fmt.Errorf("summary response was empty")
+	}
+	return strings.TrimSpace(parsed.Choices[0].Message.Content), s.Name(), nil
+}
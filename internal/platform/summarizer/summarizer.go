@@ -0,0 +1,74 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+)
+
+// Sizes supported by generated notes, matching links.generated_notes_size.
+var Sizes = []string{"tiny", "short", "medium", "long"}
+
+func ValidSize(size string) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Input is the page to summarise. Text is the readable page content; Title
+// and URL give the model context when the text is thin.
+type Input struct {
+	URL   string
+	Title string
+	Text  string
+	Size  string
+}
+
+// ErrNoText is returned when the input has nothing that reads as sentences.
+var ErrNoText = errors.New("no text to summarize")
+
+// Summarizer produces markdown notes for a page. Summarize also returns the
+// name of the summarizer that wrote them, which for a fallback chain may not
+// be Name.
+type Summarizer interface {
+	Name() string
+	Summarize(ctx context.Context, in Input) (notes, by string, err error)
+}
+
+// New returns an OpenAI-compatible summarizer backed by the extractive one
+// when SUMMARY_API_URL is set, otherwise just the extractive summarizer.
+func New() Summarizer {
+	baseURL := os.Getenv("SUMMARY_API_URL")
+	if baseURL == "" {
+		return NewExtractive()
+	}
+	model := os.Getenv("SUMMARY_MODEL")
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	return WithFallback(NewOpenAI(baseURL, os.Getenv("SUMMARY_API_KEY"), model), NewExtractive())
+}
+
+type fallback struct {
+	primary, secondary Summarizer
+}
+
+// WithFallback tries primary and falls back to secondary when it fails.
+func WithFallback(primary, secondary Summarizer) Summarizer {
+	return &fallback{primary: primary, secondary: secondary}
+}
+
+func (f *fallback) Name() string { return f.primary.Name() }
+
+func (f *fallback) Summarize(ctx context.Context, in Input) (string, string, error) {
+	notes, by, err := f.primary.Summarize(ctx, in)
+	if err == nil {
+		return notes, by, nil
+	}
+	slog.Warn("summarizer: primary failed, using fallback", "primary", f.primary.Name(), "fallback", f.secondary.Name(), "error", err)
+	return f.secondary.Summarize(ctx, in)
+}
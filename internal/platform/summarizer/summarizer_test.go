@@ -0,0 +1,71 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const prose = "The quick brown fox jumps over the lazy dog near the river bank. " +
+	"Foxes are known for their cunning and their bright orange fur in winter."
+
+func TestExtractiveNoSentences(t *testing.T) {
+	for name, text := range map[string]string{
+		"fragments": "Home. About us. Contact. Blog. Pricing.",
+		"run-on":    strings.Repeat("word ", 200),
+	} {
+		if _, _, err := NewExtractive().Summarize(context.Background(), Input{Text: text}); !errors.Is(err, ErrNoText) {
+			t.Errorf("%s: err = %v, want ErrNoText", name, err)
+		}
+	}
+}
+
+type failing struct{}
+
+func (failing) Name() string { return "model-x" }
+
+func (failing) Summarize(context.Context, Input) (string, string, error) {
+	return "", "", errors.New("unavailable")
+}
+
+func TestFallbackReportsWhoWrote(t *testing.T) {
+	s := WithFallback(failing{}, NewExtractive())
+	notes, by, err := s.Summarize(context.Background(), Input{Text: prose})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if by != "extractive" || notes == "" {
+		t.Errorf("by = %q, notes %q; want extractive notes", by, notes)
+	}
+	if s.Name() != "model-x" {
+		t.Errorf("Name = %q", s.Name())
+	}
+}
+
+func TestOpenAIPromptIsValidUTF8(t *testing.T) {
+	var sent chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		json.NewEncoder(w).Encode(map[string]any{"choices": []any{map[string]any{"message": chatMessage{Role: "assistant", Content: "## Summary\n\nOk."}}}})
+	}))
+	defer srv.Close()
+
+	// Three-byte runes put a rune boundary off the byte limit.
+	text := "a" + strings.Repeat("€", maxPromptChars)
+	_, by, err := NewOpenAI(srv.URL, "", "model-x").Summarize(context.Background(), Input{Text: text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if by != "model-x" {
+		t.Errorf("by = %q", by)
+	}
+	prompt := sent.Messages[1].Content
+	if !utf8.ValidString(prompt) || strings.ContainsRune(prompt, utf8.RuneError) {
+		t.Error("prompt is not valid UTF-8")
+	}
+}
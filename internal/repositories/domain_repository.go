@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DomainStats struct {
+	Domain      string    `json:"domain"`
+	LinkCount   int       `json:"link_count"`
+	TotalClicks int       `json:"total_clicks"`
+	AvgStars    float64   `json:"avg_stars"`
+	LastAddedAt time.Time `json:"last_added_at"`
+}
+
+type DomainRepository struct{ pool *pgxpool.Pool }
+
+func NewDomainRepository(pool *pgxpool.Pool) *DomainRepository { return &DomainRepository{pool: pool} }
+
+func (r *DomainRepository) List(ctx context.Context, ownerID, sortBy string) ([]DomainStats, error) {
+	query := `
+		SELECT 
+			domain,
+			COUNT(*) as link_count,
+			COALESCE(SUM(click_count), 0) as total_clicks,
+			COALESCE(AVG(stars), 0)::float8 as avg_stars,
+			MAX(created_at) as last_added_at
+		FROM links
+		WHERE owner_id = $1 AND domain <> ''
+		GROUP BY domain`
+
+	switch sortBy {
+	case "clicks":
+		query += ` ORDER BY total_clicks DESC, domain`
+	case "stars":
+		query += ` ORDER BY avg_stars DESC, domain`
+	case "recent":
+		query += ` ORDER BY last_added_at DESC`
+	case "name":
+		query += ` ORDER BY domain`
+	default:
+		query += ` ORDER BY link_count DESC, domain`
+	}
+
+	rows, err := r.pool.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []DomainStats{}
+	for rows.Next() {
+		var d DomainStats
+		if err := rows.Scan(&d.Domain, &d.LinkCount, &d.TotalClicks, &d.AvgStars, &d.LastAddedAt); err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	return domains, rows.Err()
+}
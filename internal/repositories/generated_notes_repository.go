@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GeneratedNotesRepository struct{ pool *pgxpool.Pool }
+
+func NewGeneratedNotesRepository(pool *pgxpool.Pool) *GeneratedNotesRepository {
+	return &GeneratedNotesRepository{pool: pool}
+}
+
+// Cached returns notes generated for the link at the given size, provided they
+// were generated from the same URL the link has now.
+func (r *GeneratedNotesRepository) Cached(ctx context.Context, linkID, size, url string) (string, error) {
+	var notes string
+	err := r.pool.QueryRow(ctx, `SELECT notes FROM generated_notes_cache WHERE link_id = $1 AND size = $2 AND url = $3`, linkID, size, url).Scan(&notes)
+	return notes, err
+}
+
+func (r *GeneratedNotesRepository) Store(ctx context.Context, linkID, size, url, notes, summarizer string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO generated_notes_cache (link_id, size, url, notes, summarizer)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (link_id, size) DO UPDATE
+		SET url = EXCLUDED.url, notes = EXCLUDED.notes, summarizer = EXCLUDED.summarizer, created_at = NOW()
+	`, linkID, size, url, notes, summarizer)
+	return err
+}
+
+// Apply makes the notes the link's current generated notes.
+func (r *GeneratedNotesRepository) Apply(ctx context.Context, linkID, notes, size string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE links SET generated_notes = $1, generated_notes_size = $2, updated_at = NOW() WHERE id = $3`, notes, size, linkID)
+	return err
+}
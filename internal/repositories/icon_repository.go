@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IconRepository struct{ pool *pgxpool.Pool }
+
+func NewIconRepository(pool *pgxpool.Pool) *IconRepository { return &IconRepository{pool: pool} }
+
+type Icon struct {
+	Hash        string
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+	SourceURL   string
+	CreatedAt   time.Time
+}
+
+type IconOverride struct {
+	Domain    string    `json:"domain"`
+	IconURL   string    `json:"icon_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (r *IconRepository) Get(ctx context.Context, hash string) (Icon, error) {
+	var icon Icon
+	err := r.pool.QueryRow(ctx, `
+		SELECT hash, content_type, data, width, height, source_url, created_at
+		FROM icons
+		WHERE hash = $1
+	`, hash).Scan(&icon.Hash, &icon.ContentType, &icon.Data, &icon.Width, &icon.Height, &icon.SourceURL, &icon.CreatedAt)
+	return icon, err
+}
+
+// Put stores an icon. Icons are content-addressed, so an existing row with
+// the same hash is left as is.
+func (r *IconRepository) Put(ctx context.Context, icon Icon) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO icons (hash, content_type, data, width, height, source_url)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (hash) DO NOTHING
+	`, icon.Hash, icon.ContentType, icon.Data, icon.Width, icon.Height, icon.SourceURL)
+	return err
+}
+
+// SetLinkIcon replaces a link's icon_url, provided it still holds the value
+// the caller based its work on. Returns pgx.ErrNoRows otherwise.
+func (r *IconRepository) SetLinkIcon(ctx context.Context, linkID, previous, iconURL string) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE links SET icon_url = $3, updated_at = NOW()
+		WHERE id = $1 AND COALESCE(icon_url, '') = $2
+	`, linkID, previous, iconURL)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *IconRepository) ListOverrides(ctx context.Context) ([]IconOverride, error) {
+	rows, err := r.pool.Query(ctx, `SELECT domain, icon_url, created_at, updated_at FROM icon_overrides ORDER BY domain`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []IconOverride{}
+	for rows.Next() {
+		var o IconOverride
+		if err := rows.Scan(&o.Domain, &o.IconURL, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+func (r *IconRepository) Override(ctx context.Context, domain string) (string, error) {
+	var iconURL string
+	err := r.pool.QueryRow(ctx, `SELECT icon_url FROM icon_overrides WHERE domain = $1`, domain).Scan(&iconURL)
+	return iconURL, err
+}
+
+func (r *IconRepository) PutOverride(ctx context.Context, domain, iconURL string) (IconOverride, error) {
+	var o IconOverride
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO icon_overrides (domain, icon_url)
+		VALUES ($1, $2)
+		ON CONFLICT (domain) DO UPDATE SET icon_url = EXCLUDED.icon_url, updated_at = NOW()
+		RETURNING domain, icon_url, created_at, updated_at
+	`, domain, iconURL).Scan(&o.Domain, &o.IconURL, &o.CreatedAt, &o.UpdatedAt)
+	return o, err
+}
+
+func (r *IconRepository) DeleteOverride(ctx context.Context, domain string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM icon_overrides WHERE domain = $1`, domain)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robstave/link-manager/internal/models"
+)
+
+type JobRepository struct{ pool *pgxpool.Pool }
+
+func NewJobRepository(pool *pgxpool.Pool) *JobRepository { return &JobRepository{pool: pool} }
+
+const jobColumns = `id, owner_id, kind, payload, status, attempts, max_attempts, last_error, result, run_at, created_at, updated_at, finished_at`
+
+func scanJob(row pgx.Row) (models.Job, error) {
+	var j models.Job
+	var ownerID *string
+	err := row.Scan(&j.ID, &ownerID, &j.Kind, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError, &j.Result, &j.RunAt, &j.CreatedAt, &j.UpdatedAt, &j.FinishedAt)
+	j.OwnerID = ownerID
+	return j, err
+}
+
+// ErrSuperseded is returned by Retry when a newer queued duplicate made the
+// retry unnecessary.
+var ErrSuperseded = errors.New("job superseded by a newer queued job")
+
+// enqueueAttempts bounds Enqueue's insert-or-find loop.
+const enqueueAttempts = 3
+
+// Enqueue inserts a queued job. When dedupeKey is set and a queued job with
+// the same kind and key exists, that job is returned instead. A running
+// duplicate doesn't count: it may have read state older than this request.
+func (r *JobRepository) Enqueue(ctx context.Context, ownerID, kind string, payload []byte, dedupeKey string, maxAttempts int, runAt time.Time) (models.Job, error) {
+	for attempt := 1; ; attempt++ {
+		job, err := scanJob(r.pool.QueryRow(ctx, `
+			INSERT INTO jobs (owner_id, kind, payload, dedupe_key, max_attempts, run_at)
+			VALUES (NULLIF($1, '')::uuid, $2, $3, NULLIF($4, ''), $5, $6)
+			ON CONFLICT (kind, dedupe_key) WHERE dedupe_key IS NOT NULL AND status = 'queued' DO NOTHING
+			RETURNING `+jobColumns,
+			ownerID, kind, payload, dedupeKey, maxAttempts, runAt))
+		if !errors.Is(err, pgx.ErrNoRows) || dedupeKey == "" {
+			return job, err
+		}
+		job, err = scanJob(r.pool.QueryRow(ctx, `
+			SELECT `+jobColumns+`
+			FROM jobs
+			WHERE kind = $1 AND dedupe_key = $2 AND status = 'queued'
+		`, kind, dedupeKey))
+		// No row means the conflicting job was claimed in between; insert again.
+		if !errors.Is(err, pgx.ErrNoRows) || attempt == enqueueAttempts {
+			return job, err
+		}
+	}
+}
+
+// Claim locks the next runnable job for workerID and marks it running. Jobs
+// left running longer than staleAfter (e.g. by a crashed worker) are reclaimed.
+// A queued job waits while a job with the same kind and dedupe key runs, so
+// the newer one finishes last. Returns pgx.ErrNoRows when nothing is runnable.
+func (r *JobRepository) Claim(ctx context.Context, workerID string, staleAfter time.Duration) (models.Job, error) {
+	return scanJob(r.pool.QueryRow(ctx, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), locked_by = $1, updated_at = NOW()
+		WHERE id = (
+			SELECT q.id FROM jobs q
+			WHERE (q.status = 'queued' AND q.run_at <= NOW() AND NOT EXISTS (
+					SELECT 1 FROM jobs r
+					WHERE r.kind = q.kind AND r.dedupe_key = q.dedupe_key
+						AND r.status = 'running' AND r.locked_at >= NOW() - make_interval(secs => $2)
+				))
+				OR (q.status = 'running' AND q.locked_at < NOW() - make_interval(secs => $2))
+			ORDER BY q.run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING `+jobColumns,
+		workerID, staleAfter.Seconds()))
+}
+
+// Complete marks a job claimed by workerID as succeeded. Complete, Retry and
+// Bury return pgx.ErrNoRows when the worker no longer holds the job, e.g.
+// because it ran past staleAfter and another worker reclaimed it.
+func (r *JobRepository) Complete(ctx context.Context, id, workerID string, result []byte) error {
+	return settled(r.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'succeeded', result = $1, last_error = NULL, locked_at = NULL, locked_by = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status = 'running' AND locked_by = $3
+	`, result, id, workerID))
+}
+
+// Retry puts a failed job back in the queue to run again at runAt. When a
+// newer duplicate was queued while it ran, that job does the work instead
+// and this one is dead-lettered as superseded; ErrSuperseded reports it.
+func (r *JobRepository) Retry(ctx context.Context, id, workerID, lastError string, runAt time.Time) error {
+	err := settled(r.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'queued', last_error = $1, run_at = $2, locked_at = NULL, locked_by = NULL, updated_at = NOW()
+		WHERE id = $3 AND status = 'running' AND locked_by = $4
+	`, lastError, runAt, id, workerID))
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" { // unique_violation on idx_jobs_dedupe
+		return err
+	}
+	if err := r.Bury(ctx, id, workerID, lastError+" (superseded by a newer queued job)"); err != nil {
+		return err
+	}
+	return ErrSuperseded
+}
+
+// Bury dead-letters a job that failed permanently or ran out of attempts.
+func (r *JobRepository) Bury(ctx context.Context, id, workerID, lastError string) error {
+	return settled(r.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = 'dead', last_error = $1, locked_at = NULL, locked_by = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status = 'running' AND locked_by = $3
+	`, lastError, id, workerID))
+}
+
+// settled maps an update that matched no row to pgx.ErrNoRows.
+func settled(tag pgconn.CommandTag, err error) error {
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Requeue resets a dead job so it runs again with a fresh set of attempts.
+func (r *JobRepository) Requeue(ctx context.Context, id, ownerID string) (models.Job, error) {
+	return scanJob(r.pool.QueryRow(ctx, `
+		UPDATE jobs
+		SET status = 'queued', attempts = 0, run_at = NOW(), finished_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND ($2 = '' OR owner_id::text = $2) AND status = 'dead'
+		RETURNING `+jobColumns,
+		id, ownerID))
+}
+
+// Get returns a job. An empty ownerID matches any owner (admin access).
+func (r *JobRepository) Get(ctx context.Context, id, ownerID string) (models.Job, error) {
+	return scanJob(r.pool.QueryRow(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE id = $1 AND ($2 = '' OR owner_id::text = $2)
+	`, id, ownerID))
+}
+
+// List returns recent jobs, newest first. Empty ownerID, status or kind
+// match everything.
+func (r *JobRepository) List(ctx context.Context, ownerID, status, kind string, limit int) ([]models.Job, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE ($1 = '' OR owner_id::text = $1)
+			AND ($2 = '' OR status = $2)
+			AND ($3 = '' OR kind = $3)
+		ORDER BY created_at DESC
+		LIMIT $4
+	`, ownerID, status, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// PurgeFinished deletes succeeded jobs that finished before the cutoff.
+func (r *JobRepository) PurgeFinished(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM jobs WHERE status = 'succeeded' AND finished_at < $1`, before)
+	return tag.RowsAffected(), err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,66 +16,98 @@ type LinkRepository struct{ pool *pgxpool.Pool }
 func NewLinkRepository(pool *pgxpool.Pool) *LinkRepository { return &LinkRepository{pool: pool} }
 
 type LinkFilters struct {
-	ProjectID  string
-	CategoryID string
-	Tag        string
-	Cart       string
-	Search     string
-	SortBy     string
-	Limit      int
-	Offset     int
-}
+	ProjectID   string
+	CategoryID  string
+	Tag         string
+	Cart        string
+	Domain      string
+	ContentType string
+	Search      string
+	SortBy      string
+	Limit       int
+	Offset      int
 
-type LinkWithMeta struct {
-	models.Link
-	ProjectName  string
-	CategoryName string
+	// Extended filters, used by smart collections. Nil or empty means unset.
+	MinStars        *int
+	MaxStars        *int
+	MinClicks       *int
+	MaxClicks       *int
+	CreatedAfter    *time.Time
+	CreatedBefore   *time.Time
+	ClickedSince    *time.Time
+	NotClickedSince *time.Time
+	TagsAll         []string
+	TagsAny         []string
+	TagsNone        []string
 }
 
-func (r *LinkRepository) List(ctx context.Context, ownerID string, f LinkFilters) ([]LinkWithMeta, int, error) {
-	query := `
-		SELECT 
-			l.id, l.owner_id, l.project_id, l.category_id, l.url, l.title, l.description,
-			l.icon_url, l.user_notes, l.generated_notes, l.generated_notes_size,
+// linkColumns is the select list shared by every query returning LinkWithMeta.
+// It expects the aliases set up by linkJoins and GROUP BY l.id, p.name, c.name.
+const linkColumns = `
+			l.id, l.owner_id, l.project_id, l.category_id, l.url, l.domain, l.title, l.description,
+			l.icon_url, l.user_notes, l.generated_notes, l.generated_notes_size, l.metadata_status,
+			l.site_name, l.author, l.published_at, l.content_type, l.duration_seconds, l.image_url, l.thumbnail_url, l.site_data, l.word_count, l.mime_type, l.content_length,
+			l.metadata_fetched_at, l.user_edited_fields,
 			l.stars, l.click_count, l.last_clicked_at, l.cart, l.created_at, l.updated_at,
 			p.name as project_name, c.name as category_name,
-			ARRAY_AGG(DISTINCT t.name) FILTER (WHERE t.name IS NOT NULL) as tags
+			ARRAY_AGG(DISTINCT t.name) FILTER (WHERE t.name IS NOT NULL) as tags`
+
+const linkJoins = `
 		FROM links l
 		LEFT JOIN projects p ON p.id = l.project_id
 		LEFT JOIN categories c ON c.id = l.category_id
 		LEFT JOIN link_tags lt ON lt.link_id = l.id
-		LEFT JOIN tags t ON t.id = lt.tag_id
-		WHERE l.owner_id = $1
-	`
-	args := []interface{}{ownerID}
-	argCount := 1
+		LEFT JOIN tags t ON t.id = lt.tag_id`
 
-	if f.ProjectID != "" {
-		argCount++
-		query += ` AND l.project_id = $` + strconv.Itoa(argCount)
-		args = append(args, f.ProjectID)
-	}
-	if f.CategoryID != "" {
-		argCount++
-		query += ` AND l.category_id = $` + strconv.Itoa(argCount)
-		args = append(args, f.CategoryID)
-	}
-	if f.Cart != "" {
-		argCount++
-		query += ` AND l.cart = $` + strconv.Itoa(argCount)
-		args = append(args, f.Cart == "true")
-	}
-	if f.Tag != "" {
-		argCount++
-		query += ` AND EXISTS (SELECT 1 FROM link_tags lt2 JOIN tags t2 ON t2.id = lt2.tag_id WHERE lt2.link_id = l.id AND t2.name = $` + strconv.Itoa(argCount) + `)`
-		args = append(args, f.Tag)
-	}
-	if f.Search != "" {
-		argCount++
-		query += ` AND l.fts @@ plainto_tsquery('english', $` + strconv.Itoa(argCount) + `)`
-		args = append(args, f.Search)
+type LinkWithMeta struct {
+	models.Link
+	ProjectName  string
+	CategoryName string
+	SearchScore  float64
+}
+
+func (r *LinkRepository) List(ctx context.Context, ownerID string, f LinkFilters) ([]LinkWithMeta, int, error) {
+	return withFuzzyFallback(f, func(fuzzy bool) ([]LinkWithMeta, int, error) {
+		return r.list(ctx, ownerID, f, fuzzy)
+	})
+}
+
+// Count returns how many links match f, applying the same fuzzy fallback as List.
+func (r *LinkRepository) Count(ctx context.Context, ownerID string, f LinkFilters) (int, error) {
+	total, _, err := withFuzzyFallback(f, func(fuzzy bool) (int, int, error) {
+		total, err := r.count(ctx, ownerID, f, fuzzy)
+		return total, total, err
+	})
+	return total, err
+}
+
+// withFuzzyFallback runs a filtered query, which reports how many links
+// matched, and when a search matched nothing runs it again fuzzily.
+func withFuzzyFallback[T any](f LinkFilters, query func(fuzzy bool) (T, int, error)) (T, int, error) {
+	result, total, err := query(false)
+	if err != nil || f.Search == "" || total > 0 {
+		return result, total, err
 	}
-	query += ` GROUP BY l.id, p.name, c.name`
+	// Nothing matched the stemmed FTS query or a URL substring; retry with
+	// trigram similarity so typos and partial identifiers still find something.
+	return query(true)
+}
+
+func (r *LinkRepository) count(ctx context.Context, ownerID string, f LinkFilters, fuzzy bool) (int, error) {
+	where, args, _ := linkFilterClause(ownerID, f, fuzzy)
+	var total int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM links l WHERE `+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *LinkRepository) list(ctx context.Context, ownerID string, f LinkFilters, fuzzy bool) ([]LinkWithMeta, int, error) {
+	where, args, score := linkFilterClause(ownerID, f, fuzzy)
+	query := `
+		SELECT ` + linkColumns + `,
+			` + score + ` as search_score
+		` + linkJoins + `
+		WHERE ` + where + `
+		GROUP BY l.id, p.name, c.name`
 
 	switch f.SortBy {
 	case "clicks":
@@ -83,18 +116,14 @@ func (r *LinkRepository) List(ctx context.Context, ownerID string, f LinkFilters
 		query += ` ORDER BY l.last_clicked_at DESC NULLS LAST, l.created_at DESC`
 	case "created":
 		query += ` ORDER BY l.created_at DESC`
+	case "relevance":
+		query += ` ORDER BY search_score DESC, l.stars DESC, l.created_at DESC`
 	default:
 		query += ` ORDER BY l.stars DESC, l.created_at DESC`
 	}
 
-	argCount++
-	query += ` LIMIT $` + strconv.Itoa(argCount)
-	args = append(args, f.Limit)
-	argCount++
-	query += ` OFFSET $` + strconv.Itoa(argCount)
-	args = append(args, f.Offset)
-
-	rows, err := r.pool.Query(ctx, query, args...)
+	query += ` LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	rows, err := r.pool.Query(ctx, query, append(args, f.Limit, f.Offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -102,28 +131,217 @@ func (r *LinkRepository) List(ctx context.Context, ownerID string, f LinkFilters
 
 	links := []LinkWithMeta{}
 	for rows.Next() {
-		var item LinkWithMeta
-		var tags []string
-		if err := rows.Scan(
-			&item.ID, &item.OwnerID, &item.ProjectID, &item.CategoryID, &item.URL,
-			&item.Title, &item.Description, &item.IconURL, &item.UserNotes,
-			&item.GeneratedNotes, &item.GeneratedNotesSize, &item.Stars,
-			&item.ClickCount, &item.LastClickedAt, &item.Cart, &item.CreatedAt, &item.UpdatedAt,
-			&item.ProjectName, &item.CategoryName, &tags,
-		); err != nil {
+		var score float64
+		item, err := scanLinkWithMeta(rows, &score)
+		if err != nil {
 			return nil, 0, err
 		}
-		item.Tags = tags
+		item.SearchScore = score
 		links = append(links, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
-	var total int
-	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM links WHERE owner_id = $1`, ownerID).Scan(&total); err != nil {
+	total, err := r.count(ctx, ownerID, f, fuzzy)
+	if err != nil {
 		total = len(links)
 	}
 	return links, total, nil
 }
 
+type FacetCount struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// LinkFacets breaks a filtered link set down by dimension for drill-down counts.
+type LinkFacets struct {
+	Projects     []FacetCount `json:"projects"`
+	Categories   []FacetCount `json:"categories"`
+	Tags         []FacetCount `json:"tags"`
+	Domains      []FacetCount `json:"domains"`
+	ContentTypes []FacetCount `json:"content_types"`
+	Stars        []FacetCount `json:"stars"`
+	Cart         []FacetCount `json:"cart"`
+}
+
+// Facets computes every facet for the links matching f in a single query over
+// the filtered set. Limit, offset and sort are ignored. As with List, search
+// falls back to fuzzy matching when the strict search has no hits.
+func (r *LinkRepository) Facets(ctx context.Context, ownerID string, f LinkFilters) (LinkFacets, error) {
+	fuzzy := false
+	if f.Search != "" {
+		n, err := r.count(ctx, ownerID, f, false)
+		if err != nil {
+			return LinkFacets{}, err
+		}
+		fuzzy = n == 0
+	}
+	where, args, _ := linkFilterClause(ownerID, f, fuzzy)
+	rows, err := r.pool.Query(ctx, `
+		WITH f AS (
+			SELECT l.id, l.project_id, l.category_id, coalesce(l.stars, 0) AS stars, l.cart, l.domain, l.content_type
+			FROM links l
+			WHERE `+where+`
+		)
+		SELECT 'project', p.id::text, p.name, count(*) FROM f JOIN projects p ON p.id = f.project_id GROUP BY p.id, p.name
+		UNION ALL
+		SELECT 'category', c.id::text, c.name, count(*) FROM f JOIN categories c ON c.id = f.category_id GROUP BY c.id, c.name
+		UNION ALL
+		SELECT 'tag', t.name, t.name, count(*) FROM f JOIN link_tags lt ON lt.link_id = f.id JOIN tags t ON t.id = lt.tag_id GROUP BY t.name
+		UNION ALL
+		SELECT 'domain', domain, domain, count(*) FROM f WHERE domain <> '' GROUP BY domain
+		UNION ALL
+		SELECT 'content_type', content_type, content_type, count(*) FROM f WHERE content_type <> '' GROUP BY content_type
+		UNION ALL
+		SELECT 'stars', bucket, bucket, count(*) FROM (
+			SELECT CASE
+				WHEN stars = 0 THEN 'unrated'
+				WHEN stars <= 3 THEN '1-3'
+				WHEN stars <= 6 THEN '4-6'
+				WHEN stars <= 8 THEN '7-8'
+				ELSE '9-10'
+			END AS bucket
+			FROM f
+		) b GROUP BY bucket
+		UNION ALL
+		SELECT 'cart', cart::text, CASE WHEN cart THEN 'In cart' ELSE 'Not in cart' END, count(*) FROM f GROUP BY cart
+		ORDER BY 1, 4 DESC, 3
+	`, args...)
+	if err != nil {
+		return LinkFacets{}, err
+	}
+	defer rows.Close()
+
+	facets := LinkFacets{Projects: []FacetCount{}, Categories: []FacetCount{}, Tags: []FacetCount{}, Domains: []FacetCount{}, ContentTypes: []FacetCount{}, Stars: []FacetCount{}, Cart: []FacetCount{}}
+	for rows.Next() {
+		var facet string
+		var fc FacetCount
+		if err := rows.Scan(&facet, &fc.Key, &fc.Label, &fc.Count); err != nil {
+			return LinkFacets{}, err
+		}
+		switch facet {
+		case "project":
+			facets.Projects = append(facets.Projects, fc)
+		case "category":
+			facets.Categories = append(facets.Categories, fc)
+		case "tag":
+			facets.Tags = append(facets.Tags, fc)
+		case "domain":
+			facets.Domains = append(facets.Domains, fc)
+		case "content_type":
+			facets.ContentTypes = append(facets.ContentTypes, fc)
+		case "stars":
+			facets.Stars = append(facets.Stars, fc)
+		case "cart":
+			facets.Cart = append(facets.Cart, fc)
+		}
+	}
+	return facets, rows.Err()
+}
+
+// linkFilterClause builds the WHERE clause shared by the listing and count
+// queries, along with a search score expression. With fuzzy set, the search
+// term is matched by trigram similarity instead of FTS and URL substring.
+func linkFilterClause(ownerID string, f LinkFilters, fuzzy bool) (string, []interface{}, string) {
+	where := `l.owner_id = $1`
+	args := []interface{}{ownerID}
+	argCount := 1
+	score := `0::float8`
+
+	if f.ProjectID != "" {
+		argCount++
+		where += ` AND l.project_id = $` + strconv.Itoa(argCount)
+		args = append(args, f.ProjectID)
+	}
+	if f.CategoryID != "" {
+		argCount++
+		where += ` AND l.category_id = $` + strconv.Itoa(argCount)
+		args = append(args, f.CategoryID)
+	}
+	if f.Cart != "" {
+		argCount++
+		where += ` AND l.cart = $` + strconv.Itoa(argCount)
+		args = append(args, f.Cart == "true")
+	}
+	if f.Tag != "" {
+		argCount++
+		where += ` AND EXISTS (SELECT 1 FROM link_tags lt2 JOIN tags t2 ON t2.id = lt2.tag_id WHERE lt2.link_id = l.id AND t2.name = $` + strconv.Itoa(argCount) + `)`
+		args = append(args, f.Tag)
+	}
+	if f.Domain != "" {
+		argCount++
+		where += ` AND l.domain = $` + strconv.Itoa(argCount)
+		args = append(args, strings.ToLower(f.Domain))
+	}
+	if f.ContentType != "" {
+		argCount++
+		where += ` AND l.content_type = $` + strconv.Itoa(argCount)
+		args = append(args, f.ContentType)
+	}
+	addCond := func(cond string, value interface{}) {
+		argCount++
+		where += ` AND ` + strings.ReplaceAll(cond, "?", `$`+strconv.Itoa(argCount))
+		args = append(args, value)
+	}
+	if f.MinStars != nil {
+		addCond(`coalesce(l.stars, 0) >= ?`, *f.MinStars)
+	}
+	if f.MaxStars != nil {
+		addCond(`coalesce(l.stars, 0) <= ?`, *f.MaxStars)
+	}
+	if f.MinClicks != nil {
+		addCond(`l.click_count >= ?`, *f.MinClicks)
+	}
+	if f.MaxClicks != nil {
+		addCond(`l.click_count <= ?`, *f.MaxClicks)
+	}
+	if f.CreatedAfter != nil {
+		addCond(`l.created_at >= ?`, *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		addCond(`l.created_at < ?`, *f.CreatedBefore)
+	}
+	if f.ClickedSince != nil {
+		addCond(`l.last_clicked_at >= ?`, *f.ClickedSince)
+	}
+	if f.NotClickedSince != nil {
+		addCond(`(l.last_clicked_at IS NULL OR l.last_clicked_at < ?)`, *f.NotClickedSince)
+	}
+	if len(f.TagsAll) > 0 {
+		addCond(`(SELECT count(DISTINCT t2.name) FROM link_tags lt2 JOIN tags t2 ON t2.id = lt2.tag_id WHERE lt2.link_id = l.id AND t2.name = ANY(?::text[])) = cardinality(?::text[])`, f.TagsAll)
+	}
+	if len(f.TagsAny) > 0 {
+		addCond(`EXISTS (SELECT 1 FROM link_tags lt2 JOIN tags t2 ON t2.id = lt2.tag_id WHERE lt2.link_id = l.id AND t2.name = ANY(?::text[]))`, f.TagsAny)
+	}
+	if len(f.TagsNone) > 0 {
+		addCond(`NOT EXISTS (SELECT 1 FROM link_tags lt2 JOIN tags t2 ON t2.id = lt2.tag_id WHERE lt2.link_id = l.id AND t2.name = ANY(?::text[]))`, f.TagsNone)
+	}
+	if f.Search != "" {
+		argCount++
+		q := `$` + strconv.Itoa(argCount)
+		args = append(args, f.Search)
+		if fuzzy {
+			where += ` AND (` + q + ` <% l.title OR ` + q + ` <% l.url OR l.title % ` + q + `)`
+			score = `GREATEST(similarity(l.title, ` + q + `), word_similarity(` + q + `, l.title), word_similarity(` + q + `, l.url))::float8`
+		} else {
+			argCount++
+			like := `$` + strconv.Itoa(argCount)
+			args = append(args, "%"+escapeLike(f.Search)+"%")
+			where += ` AND (l.fts @@ plainto_tsquery('english', ` + q + `) OR l.url ILIKE ` + like + `)`
+			score = `(ts_rank_cd(l.fts, plainto_tsquery('english', ` + q + `)) + similarity(l.title, ` + q + `) + CASE WHEN l.url ILIKE ` + like + ` THEN 0.5 ELSE 0 END)::float8`
+		}
+	}
+	return where, args, score
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 func (r *LinkRepository) DefaultProjectID(ctx context.Context, ownerID string) (string, error) {
 	var id string
 	err := r.pool.QueryRow(ctx, `SELECT id FROM projects WHERE owner_id = $1 AND is_default = true`, ownerID).Scan(&id)
@@ -136,18 +354,26 @@ func (r *LinkRepository) DefaultCategoryID(ctx context.Context, projectID string
 	return id, err
 }
 
-func (r *LinkRepository) Create(ctx context.Context, ownerID, projectID, categoryID, url, title, description, userNotes, iconURL string, stars int, tags []string) (models.Link, error) {
+// Create inserts a link. editedFields lists which of title, description and
+// icon_url the user supplied; see user_edited_fields. createdAt backdates
+// imported links; nil means now.
+func (r *LinkRepository) Create(ctx context.Context, ownerID, projectID, categoryID, url, domain, title, description, userNotes, iconURL, metadataStatus string, editedFields []string, stars int, tags []string, createdAt *time.Time) (models.Link, error) {
 	var link models.Link
+	if editedFields == nil {
+		editedFields = []string{}
+	}
 	err := r.pool.QueryRow(ctx, `
-		INSERT INTO links (owner_id, project_id, category_id, url, title, description, user_notes, icon_url, stars)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, owner_id, project_id, category_id, url, title, description, icon_url,
-			user_notes, generated_notes, generated_notes_size, stars, click_count, 
-			last_clicked_at, cart, created_at, updated_at
-	`, ownerID, projectID, categoryID, url, title, description, userNotes, iconURL, stars).Scan(
-		&link.ID, &link.OwnerID, &link.ProjectID, &link.CategoryID, &link.URL,
+		INSERT INTO links (owner_id, project_id, category_id, url, domain, title, description, user_notes, icon_url, stars, metadata_status, user_edited_fields, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE($13, NOW()))
+		RETURNING id, owner_id, project_id, category_id, url, domain, title, description, icon_url,
+			user_notes, generated_notes, generated_notes_size, metadata_status,
+			site_name, author, published_at, content_type, duration_seconds, image_url, thumbnail_url, site_data, word_count, mime_type, content_length, metadata_fetched_at, user_edited_fields,
+			stars, click_count, last_clicked_at, cart, created_at, updated_at
+	`, ownerID, projectID, categoryID, url, domain, title, description, userNotes, iconURL, stars, metadataStatus, editedFields, createdAt).Scan(
+		&link.ID, &link.OwnerID, &link.ProjectID, &link.CategoryID, &link.URL, &link.Domain,
 		&link.Title, &link.Description, &link.IconURL, &link.UserNotes,
-		&link.GeneratedNotes, &link.GeneratedNotesSize, &link.Stars,
+		&link.GeneratedNotes, &link.GeneratedNotesSize, &link.MetadataStatus,
+		&link.SiteName, &link.Author, &link.PublishedAt, &link.ContentType, &link.DurationSeconds, &link.ImageURL, &link.ThumbnailURL, &link.SiteData, &link.WordCount, &link.MimeType, &link.ContentLength, &link.MetadataFetchedAt, &link.UserEditedFields, &link.Stars,
 		&link.ClickCount, &link.LastClickedAt, &link.Cart, &link.CreatedAt, &link.UpdatedAt,
 	)
 	if err != nil {
@@ -175,34 +401,12 @@ func (r *LinkRepository) Create(ctx context.Context, ownerID, projectID, categor
 }
 
 func (r *LinkRepository) Get(ctx context.Context, linkID, ownerID string) (LinkWithMeta, error) {
-	var item LinkWithMeta
-	var tags []string
-	err := r.pool.QueryRow(ctx, `
-		SELECT 
-			l.id, l.owner_id, l.project_id, l.category_id, l.url, l.title, l.description,
-			l.icon_url, l.user_notes, l.generated_notes, l.generated_notes_size,
-			l.stars, l.click_count, l.last_clicked_at, l.cart, l.created_at, l.updated_at,
-			p.name as project_name, c.name as category_name,
-			ARRAY_AGG(DISTINCT t.name) FILTER (WHERE t.name IS NOT NULL) as tags
-		FROM links l
-		LEFT JOIN projects p ON p.id = l.project_id
-		LEFT JOIN categories c ON c.id = l.category_id
-		LEFT JOIN link_tags lt ON lt.link_id = l.id
-		LEFT JOIN tags t ON t.id = lt.tag_id
+	return scanLinkWithMeta(r.pool.QueryRow(ctx, `
+		SELECT `+linkColumns+`
+		`+linkJoins+`
 		WHERE l.id = $1 AND l.owner_id = $2
 		GROUP BY l.id, p.name, c.name
-	`, linkID, ownerID).Scan(
-		&item.ID, &item.OwnerID, &item.ProjectID, &item.CategoryID, &item.URL,
-		&item.Title, &item.Description, &item.IconURL, &item.UserNotes,
-		&item.GeneratedNotes, &item.GeneratedNotesSize, &item.Stars,
-		&item.ClickCount, &item.LastClickedAt, &item.Cart, &item.CreatedAt, &item.UpdatedAt,
-		&item.ProjectName, &item.CategoryName, &tags,
-	)
-	if err != nil {
-		return LinkWithMeta{}, err
-	}
-	item.Tags = tags
-	return item, nil
+	`, linkID, ownerID))
 }
 
 func (r *LinkRepository) Click(ctx context.Context, linkID, ownerID string) (string, error) {
@@ -211,7 +415,12 @@ func (r *LinkRepository) Click(ctx context.Context, linkID, ownerID string) (str
 	return url, err
 }
 
-func (r *LinkRepository) Update(ctx context.Context, ownerID, linkID string, projectID, categoryID, url, title, description, userNotes, iconURL string, stars int, tags []string) error {
+// Update saves user edits. A non-empty metadataStatus replaces the current
+// one; empty leaves it alone. editedFields replaces user_edited_fields.
+func (r *LinkRepository) Update(ctx context.Context, ownerID, linkID string, projectID, categoryID, url, domain, title, description, userNotes, iconURL, metadataStatus string, editedFields []string, stars int, tags []string) error {
+	if editedFields == nil {
+		editedFields = []string{}
+	}
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return err
@@ -220,9 +429,11 @@ func (r *LinkRepository) Update(ctx context.Context, ownerID, linkID string, pro
 
 	_, err = tx.Exec(ctx, `
 		UPDATE links 
-		SET project_id = $1, category_id = $2, url = $3, title = $4, description = $5, user_notes = $6, stars = $7, icon_url = $8, updated_at = NOW()
-		WHERE id = $9 AND owner_id = $10
-	`, projectID, categoryID, url, title, description, userNotes, stars, iconURL, linkID, ownerID)
+		SET project_id = $1, category_id = $2, url = $3, domain = $4, title = $5, description = $6, user_notes = $7, stars = $8,
+			icon_url = CASE WHEN $9 <> '' THEN $9 WHEN url <> $3 THEN '' ELSE icon_url END,
+			metadata_status = COALESCE(NULLIF($12, ''), metadata_status), user_edited_fields = $13, updated_at = NOW()
+		WHERE id = $10 AND owner_id = $11
+	`, projectID, categoryID, url, domain, title, description, userNotes, stars, iconURL, linkID, ownerID, metadataStatus, editedFields)
 	if err != nil {
 		return err
 	}
@@ -269,23 +480,49 @@ func (r *LinkRepository) Delete(ctx context.Context, linkID, ownerID string) err
 	return err
 }
 
-func (r *LinkRepository) Export(ctx context.Context, ownerID string) ([]LinkWithMeta, error) {
+// LinkURL is a link's id and URL, for duplicate checks.
+type LinkURL struct {
+	ID  string
+	URL string
+}
+
+func (r *LinkRepository) URLs(ctx context.Context, ownerID string) ([]LinkURL, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, url FROM links WHERE owner_id = $1`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []LinkURL
+	for rows.Next() {
+		var u LinkURL
+		if err := rows.Scan(&u.ID, &u.URL); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// Export returns every link matching f, newest first, with the same fuzzy
+// search fallback as List. Sorting and paging do not apply.
+func (r *LinkRepository) Export(ctx context.Context, ownerID string, f LinkFilters) ([]LinkWithMeta, error) {
+	links, _, err := withFuzzyFallback(f, func(fuzzy bool) ([]LinkWithMeta, int, error) {
+		links, err := r.export(ctx, ownerID, f, fuzzy)
+		return links, len(links), err
+	})
+	return links, err
+}
+
+func (r *LinkRepository) export(ctx context.Context, ownerID string, f LinkFilters, fuzzy bool) ([]LinkWithMeta, error) {
+	where, args, _ := linkFilterClause(ownerID, f, fuzzy)
 	rows, err := r.pool.Query(ctx, `
-		SELECT 
-			l.id, l.owner_id, l.project_id, l.category_id, l.url, l.title, l.description,
-			l.icon_url, l.user_notes, l.generated_notes, l.generated_notes_size,
-			l.stars, l.click_count, l.last_clicked_at, l.cart, l.created_at, l.updated_at,
-			p.name as project_name, c.name as category_name,
-			ARRAY_AGG(DISTINCT t.name) FILTER (WHERE t.name IS NOT NULL) as tags
-		FROM links l
-		LEFT JOIN projects p ON p.id = l.project_id
-		LEFT JOIN categories c ON c.id = l.category_id
-		LEFT JOIN link_tags lt ON lt.link_id = l.id
-		LEFT JOIN tags t ON t.id = lt.tag_id
-		WHERE l.owner_id = $1
+		SELECT `+linkColumns+`
+		`+linkJoins+`
+		WHERE `+where+`
 		GROUP BY l.id, p.name, c.name
 		ORDER BY l.created_at DESC
-	`, ownerID)
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -293,21 +530,374 @@ func (r *LinkRepository) Export(ctx context.Context, ownerID string) ([]LinkWith
 
 	links := []LinkWithMeta{}
 	for rows.Next() {
-		var item LinkWithMeta
-		var tags []string
-		if err := rows.Scan(
-			&item.ID, &item.OwnerID, &item.ProjectID, &item.CategoryID, &item.URL,
-			&item.Title, &item.Description, &item.IconURL, &item.UserNotes,
-			&item.GeneratedNotes, &item.GeneratedNotesSize, &item.Stars,
-			&item.ClickCount, &item.LastClickedAt, &item.Cart, &item.CreatedAt, &item.UpdatedAt,
-			&item.ProjectName, &item.CategoryName, &tags,
-		); err != nil {
+		item, err := scanLinkWithMeta(rows)
+		if err != nil {
 			return nil, err
 		}
-		item.Tags = tags
 		links = append(links, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return links, nil
 }
 
+// EmbeddingSource is the text a link's embedding is computed from.
+type EmbeddingSource struct {
+	ID, URL, Title, Description, UserNotes, GeneratedNotes string
+}
+
+func (r *LinkRepository) EmbeddingSource(ctx context.Context, linkID string) (EmbeddingSource, error) {
+	var src EmbeddingSource
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, url, coalesce(title, ''), coalesce(description, ''), coalesce(user_notes, ''), coalesce(generated_notes, '')
+		FROM links WHERE id = $1
+	`, linkID).Scan(&src.ID, &src.URL, &src.Title, &src.Description, &src.UserNotes, &src.GeneratedNotes)
+	return src, err
+}
+
+// MissingEmbeddings returns links that have no embedding from the given model.
+func (r *LinkRepository) MissingEmbeddings(ctx context.Context, model string, limit int) ([]EmbeddingSource, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, url, coalesce(title, ''), coalesce(description, ''), coalesce(user_notes, ''), coalesce(generated_notes, '')
+		FROM links
+		WHERE embedding IS NULL OR embedding_model IS DISTINCT FROM $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, model, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []EmbeddingSource{}
+	for rows.Next() {
+		var src EmbeddingSource
+		if err := rows.Scan(&src.ID, &src.URL, &src.Title, &src.Description, &src.UserNotes, &src.GeneratedNotes); err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+// SetEmbedding stores a vector given in pgvector text form, e.g. "[0.1,0.2]".
+func (r *LinkRepository) SetEmbedding(ctx context.Context, linkID, model, vector string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE links SET embedding = $1::vector, embedding_model = $2, embedded_at = NOW() WHERE id = $3`, vector, model, linkID)
+	return err
+}
+
+// SemanticSearch returns the owner's links nearest to vector by cosine
+// distance. SearchScore holds the cosine similarity.
+func (r *LinkRepository) SemanticSearch(ctx context.Context, ownerID, model, vector string, limit int) ([]LinkWithMeta, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH nn AS (
+			SELECT id, embedding <=> $3::vector AS distance
+			FROM links
+			WHERE owner_id = $1 AND embedding_model = $2 AND embedding IS NOT NULL
+			ORDER BY embedding <=> $3::vector
+			LIMIT $4
+		)
+		SELECT `+linkColumns+`,
+			(1 - nn.distance)::float8 as search_score
+		`+linkJoins+`
+		JOIN nn ON nn.id = l.id
+		GROUP BY l.id, p.name, c.name, nn.distance
+		ORDER BY nn.distance
+	`, ownerID, model, vector, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []LinkWithMeta{}
+	for rows.Next() {
+		var score float64
+		item, err := scanLinkWithMeta(rows, &score)
+		if err != nil {
+			return nil, err
+		}
+		item.SearchScore = score
+		links = append(links, item)
+	}
+	return links, rows.Err()
+}
+
+// SimilarLink is a candidate neighbour with the individual signals that make
+// up its combined score.
+type SimilarLink struct {
+	LinkWithMeta
+	Score       float64
+	VectorScore float64
+	TagScore    float64
+	SharedTags  []string
+	SameDomain  bool
+	Domain      string
+	TextScore   float64
+}
+
+// Similar ranks the owner's other links against linkID across all projects.
+// The score blends cosine similarity of embeddings (when the source link has
+// one), Jaccard overlap of tags, a same-domain bonus and FTS overlap with the
+// source's title and description. Without an embedding, the remaining signals
+// are reweighted to fill the gap.
+func (r *LinkRepository) Similar(ctx context.Context, linkID, ownerID string, limit int) ([]SimilarLink, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH src AS (
+			SELECT s.id, s.owner_id, s.embedding, s.embedding_model,
+				s.domain,
+				replace(plainto_tsquery('english', coalesce(s.title, '') || ' ' || coalesce(s.description, ''))::text, ' & ', ' | ') AS terms,
+				(SELECT count(*) FROM link_tags WHERE link_id = s.id) AS tag_count
+			FROM links s
+			WHERE s.id = $1 AND s.owner_id = $2
+		),
+		candidates AS (
+			SELECT l.id,
+				CASE WHEN src.embedding IS NOT NULL AND l.embedding IS NOT NULL AND l.embedding_model = src.embedding_model
+					THEN GREATEST(1 - (l.embedding <=> src.embedding), 0) ELSE 0 END::float8 AS vector_score,
+				ARRAY(
+					SELECT t2.name
+					FROM link_tags a
+					JOIN link_tags b ON b.tag_id = a.tag_id AND b.link_id = src.id
+					JOIN tags t2 ON t2.id = a.tag_id
+					WHERE a.link_id = l.id
+					ORDER BY t2.name
+				) AS shared_tags,
+				(SELECT count(*) FROM link_tags WHERE link_id = l.id) AS tag_count,
+				src.tag_count AS src_tag_count,
+				l.domain,
+				(src.domain <> '' AND l.domain = src.domain) AS same_domain,
+				CASE WHEN src.terms <> '' THEN LEAST(ts_rank(l.fts, src.terms::tsquery) * 10, 1) ELSE 0 END::float8 AS text_score,
+				src.embedding IS NOT NULL AS has_vector
+			FROM links l
+			CROSS JOIN src
+			WHERE l.owner_id = src.owner_id AND l.id <> src.id
+		),
+		scored AS (
+			SELECT id, vector_score, shared_tags, domain, same_domain, text_score, has_vector,
+				CASE WHEN cardinality(shared_tags) = 0 THEN 0
+					ELSE cardinality(shared_tags)::float8 / (src_tag_count + tag_count - cardinality(shared_tags))
+				END::float8 AS tag_score
+			FROM candidates
+		),
+		ranked AS (
+			SELECT *,
+				CASE WHEN has_vector
+					THEN 0.5 * vector_score + 0.25 * tag_score + 0.1 * same_domain::int + 0.15 * text_score
+					ELSE 0.5 * tag_score + 0.2 * same_domain::int + 0.3 * text_score
+				END::float8 AS score
+			FROM scored
+		)
+		SELECT `+linkColumns+`,
+			s.score, s.vector_score, s.tag_score, s.shared_tags, s.same_domain, s.domain, s.text_score
+		`+linkJoins+`
+		JOIN (SELECT * FROM ranked WHERE score > 0.05 ORDER BY score DESC LIMIT $3) s ON s.id = l.id
+		GROUP BY l.id, p.name, c.name, s.score, s.vector_score, s.tag_score, s.shared_tags, s.same_domain, s.domain, s.text_score
+		ORDER BY s.score DESC
+	`, linkID, ownerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SimilarLink{}
+	for rows.Next() {
+		var sl SimilarLink
+		item, err := scanLinkWithMeta(rows, &sl.Score, &sl.VectorScore, &sl.TagScore, &sl.SharedTags, &sl.SameDomain, &sl.Domain, &sl.TextScore)
+		if err != nil {
+			return nil, err
+		}
+		sl.LinkWithMeta = item
+		results = append(results, sl)
+	}
+	return results, rows.Err()
+}
+
+// DomainSource is a link whose domain has not been computed yet.
+type DomainSource struct {
+	ID, URL string
+}
+
+// MissingDomains returns links without a domain in id order, starting after
+// afterID (empty for the first page), so callers can page past links whose
+// URL yields no domain.
+func (r *LinkRepository) MissingDomains(ctx context.Context, afterID string, limit int) ([]DomainSource, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, url FROM links
+		WHERE domain = '' AND ($1 = '' OR id > NULLIF($1, '')::uuid)
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []DomainSource{}
+	for rows.Next() {
+		var src DomainSource
+		if err := rows.Scan(&src.ID, &src.URL); err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+func (r *LinkRepository) SetDomain(ctx context.Context, linkID, domain string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE links SET domain = $1 WHERE id = $2`, domain, linkID)
+	return err
+}
+
+// LinkMetadata is what background enrichment learned about a link's page.
+type LinkMetadata struct {
+	Title, Description, IconURL string
+	SiteName, Author            string
+	PublishedAt                 *time.Time
+	ContentType                 string
+	DurationSeconds             *int
+	ImageURL                    string
+	SiteData                    map[string]string
+	ContentText                 string
+	WordCount                   int
+	MimeType                    string
+	ContentLength               *int64
+}
+
+// ApplyMetadata stores the result of a background fetch, marks enrichment
+// ready and records the fetch time. Title and description are replaced unless
+// listed in user_edited_fields, so anything the user typed wins; the icon is
+// only filled while empty, since the icon job keeps it current. The
+// structured fields are replaced when the fetch found a value, site data and
+// content text as a whole, as are the media type and size. Nothing is written
+// if the URL changed since the fetch started. Returns pgx.ErrNoRows when the
+// link or URL no longer matches.
+func (r *LinkRepository) ApplyMetadata(ctx context.Context, linkID, url string, m LinkMetadata) error {
+	siteData := m.SiteData
+	if siteData == nil {
+		siteData = map[string]string{}
+	}
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE links
+		SET title = CASE WHEN $3 = '' OR 'title' = ANY(user_edited_fields) THEN title ELSE $3 END,
+			description = CASE WHEN $4 = '' OR 'description' = ANY(user_edited_fields) THEN description ELSE $4 END,
+			icon_url = CASE WHEN COALESCE(icon_url, '') = '' AND NOT 'icon_url' = ANY(user_edited_fields) THEN $5 ELSE icon_url END,
+			site_name = COALESCE(NULLIF($6, ''), site_name),
+			author = COALESCE(NULLIF($7, ''), author),
+			published_at = COALESCE($8, published_at),
+			content_type = COALESCE(NULLIF($9, ''), content_type),
+			duration_seconds = COALESCE($10, duration_seconds),
+			image_url = COALESCE(NULLIF($11, ''), image_url),
+			site_data = CASE WHEN $12::jsonb = '{}'::jsonb THEN site_data ELSE $12::jsonb END,
+			content_text = COALESCE(NULLIF($13, ''), content_text),
+			word_count = CASE WHEN $13 <> '' THEN $14 ELSE word_count END,
+			mime_type = COALESCE(NULLIF($15, ''), mime_type),
+			content_length = COALESCE($16, content_length),
+			metadata_status = 'ready',
+			metadata_fetched_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND url = $2
+	`, linkID, url, m.Title, m.Description, m.IconURL, m.SiteName, m.Author, m.PublishedAt, m.ContentType, m.DurationSeconds, m.ImageURL, siteData, m.ContentText, m.WordCount, m.MimeType, m.ContentLength)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SetMetadataStatus sets a link's enrichment status. A failure counts as a
+// fetch for refresh scheduling, so dead pages aren't retried on every sweep.
+func (r *LinkRepository) SetMetadataStatus(ctx context.Context, linkID, status string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE links
+		SET metadata_status = $1,
+			metadata_fetched_at = CASE WHEN $1 = 'failed' THEN NOW() ELSE metadata_fetched_at END
+		WHERE id = $2
+	`, status, linkID)
+	return err
+}
+
+// TouchMetadataFetched records a fetch attempt for a background refresh that
+// failed. The status is left alone unless it is still pending: a user's
+// refresh may have joined the queued background job, and would otherwise
+// stay pending, and unswept, for good.
+func (r *LinkRepository) TouchMetadataFetched(ctx context.Context, linkID string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE links
+		SET metadata_fetched_at = NOW(),
+			metadata_status = CASE WHEN metadata_status = 'pending' THEN 'failed' ELSE metadata_status END
+		WHERE id = $1
+	`, linkID)
+	return err
+}
+
+// RequestRefresh marks a link's enrichment pending and drops reset from its
+// user-edited fields, so the next fetch may replace them. Returns the link's
+// URL.
+func (r *LinkRepository) RequestRefresh(ctx context.Context, linkID, ownerID string, reset []string) (string, error) {
+	if reset == nil {
+		reset = []string{}
+	}
+	var url string
+	err := r.pool.QueryRow(ctx, `
+		UPDATE links
+		SET metadata_status = 'pending',
+			user_edited_fields = ARRAY(SELECT f FROM unnest(user_edited_fields) f WHERE NOT f = ANY($3::text[]))
+		WHERE id = $1 AND owner_id = $2
+		RETURNING url
+	`, linkID, ownerID, reset).Scan(&url)
+	return url, err
+}
+
+// StaleLink is a link due for a metadata refresh.
+type StaleLink struct {
+	ID, OwnerID, URL string
+}
+
+// StaleMetadata returns up to limit links last fetched before the cutoff,
+// never-fetched and oldest first. Links with enrichment pending are skipped.
+func (r *LinkRepository) StaleMetadata(ctx context.Context, before time.Time, limit int) ([]StaleLink, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, owner_id, url
+		FROM links
+		WHERE metadata_status <> 'pending' AND url <> ''
+			AND (metadata_fetched_at IS NULL OR metadata_fetched_at < $1)
+		ORDER BY metadata_fetched_at NULLS FIRST
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []StaleLink
+	for rows.Next() {
+		var l StaleLink
+		if err := rows.Scan(&l.ID, &l.OwnerID, &l.URL); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func scanLinkWithMeta(row pgx.Row, extra ...any) (LinkWithMeta, error) {
+	var item LinkWithMeta
+	var tags []string
+	dest := append([]any{
+		&item.ID, &item.OwnerID, &item.ProjectID, &item.CategoryID, &item.URL, &item.Domain,
+		&item.Title, &item.Description, &item.IconURL, &item.UserNotes,
+		&item.GeneratedNotes, &item.GeneratedNotesSize, &item.MetadataStatus,
+		&item.SiteName, &item.Author, &item.PublishedAt, &item.ContentType, &item.DurationSeconds, &item.ImageURL, &item.ThumbnailURL, &item.SiteData, &item.WordCount, &item.MimeType, &item.ContentLength, &item.MetadataFetchedAt, &item.UserEditedFields, &item.Stars,
+		&item.ClickCount, &item.LastClickedAt, &item.Cart, &item.CreatedAt, &item.UpdatedAt,
+		&item.ProjectName, &item.CategoryName, &tags,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return LinkWithMeta{}, err
+	}
+	item.Tags = tags
+	return item, nil
+}
+
 func IsNoRows(err error) bool { return err == pgx.ErrNoRows }
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MetadataCacheRepository struct{ pool *pgxpool.Pool }
+
+func NewMetadataCacheRepository(pool *pgxpool.Pool) *MetadataCacheRepository {
+	return &MetadataCacheRepository{pool: pool}
+}
+
+// MetadataCacheEntry is a cached fetch result: Meta (JSON) on success, Error
+// on failure. A failure with an HTTP error status also keeps that status,
+// the final URL and the redirects in Meta.
+type MetadataCacheEntry struct {
+	URL          string
+	Meta         []byte
+	Error        string
+	ETag         string
+	LastModified string
+	FetchedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func (r *MetadataCacheRepository) Get(ctx context.Context, url string) (MetadataCacheEntry, error) {
+	var e MetadataCacheEntry
+	var fetchErr *string
+	err := r.pool.QueryRow(ctx, `
+		SELECT url, meta, error, etag, last_modified, fetched_at, expires_at
+		FROM url_metadata_cache
+		WHERE url = $1
+	`, url).Scan(&e.URL, &e.Meta, &fetchErr, &e.ETag, &e.LastModified, &e.FetchedAt, &e.ExpiresAt)
+	if fetchErr != nil {
+		e.Error = *fetchErr
+	}
+	return e, err
+}
+
+func (r *MetadataCacheRepository) Put(ctx context.Context, e MetadataCacheEntry) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO url_metadata_cache (url, meta, error, etag, last_modified, fetched_at, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NOW(), $6)
+		ON CONFLICT (url) DO UPDATE
+		SET meta = EXCLUDED.meta, error = EXCLUDED.error, etag = EXCLUDED.etag,
+			last_modified = EXCLUDED.last_modified, fetched_at = NOW(), expires_at = EXCLUDED.expires_at
+	`, e.URL, e.Meta, e.Error, e.ETag, e.LastModified, e.ExpiresAt)
+	return err
+}
+
+// Touch extends an entry after the origin answered 304 Not Modified.
+func (r *MetadataCacheRepository) Touch(ctx context.Context, url string, expiresAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE url_metadata_cache SET fetched_at = NOW(), expires_at = $2 WHERE url = $1`, url, expiresAt)
+	return err
+}
+
+// PurgeExpired deletes entries that expired before the cutoff.
+func (r *MetadataCacheRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM url_metadata_cache WHERE expires_at < $1`, before)
+	return tag.RowsAffected(), err
+}
@@ -11,6 +11,9 @@ type ProjectWithCounts struct {
 	models.Project
 	CategoryCount int `json:"category_count"`
 	LinkCount     int `json:"link_count"`
+	// Virtual marks a smart collection listed as a folder alongside projects.
+	// Its links come from GET /collections/{id}/links.
+	Virtual bool `json:"virtual,omitempty"`
 }
 
 type ProjectRepository struct{ pool *pgxpool.Pool }
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robstave/link-manager/internal/models"
+)
+
+type SmartCollectionRepository struct{ pool *pgxpool.Pool }
+
+func NewSmartCollectionRepository(pool *pgxpool.Pool) *SmartCollectionRepository {
+	return &SmartCollectionRepository{pool: pool}
+}
+
+const smartCollectionColumns = `id, owner_id, name, description, filters, is_builtin, display_order, created_at, updated_at`
+
+func scanSmartCollection(row pgx.Row) (models.SmartCollection, error) {
+	var sc models.SmartCollection
+	var filters []byte
+	if err := row.Scan(&sc.ID, &sc.OwnerID, &sc.Name, &sc.Description, &filters, &sc.IsBuiltin, &sc.DisplayOrder, &sc.CreatedAt, &sc.UpdatedAt); err != nil {
+		return models.SmartCollection{}, err
+	}
+	if err := json.Unmarshal(filters, &sc.Filters); err != nil {
+		return models.SmartCollection{}, err
+	}
+	return sc, nil
+}
+
+func (r *SmartCollectionRepository) List(ctx context.Context, ownerID string) ([]models.SmartCollection, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+smartCollectionColumns+`
+		FROM smart_collections
+		WHERE owner_id = $1
+		ORDER BY display_order, created_at
+	`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.SmartCollection{}
+	for rows.Next() {
+		sc, err := scanSmartCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, sc)
+	}
+	return collections, rows.Err()
+}
+
+func (r *SmartCollectionRepository) Get(ctx context.Context, id, ownerID string) (models.SmartCollection, error) {
+	return scanSmartCollection(r.pool.QueryRow(ctx, `
+		SELECT `+smartCollectionColumns+`
+		FROM smart_collections
+		WHERE id = $1 AND owner_id = $2
+	`, id, ownerID))
+}
+
+func (r *SmartCollectionRepository) Create(ctx context.Context, ownerID, name, description string, filters models.SmartFilter) (models.SmartCollection, error) {
+	raw, err := json.Marshal(filters)
+	if err != nil {
+		return models.SmartCollection{}, err
+	}
+	return scanSmartCollection(r.pool.QueryRow(ctx, `
+		INSERT INTO smart_collections (owner_id, name, description, filters, display_order)
+		VALUES ($1, $2, $3, $4, (SELECT coalesce(max(display_order), -1) + 1 FROM smart_collections WHERE owner_id = $1))
+		RETURNING `+smartCollectionColumns+`
+	`, ownerID, name, description, raw))
+}
+
+func (r *SmartCollectionRepository) Update(ctx context.Context, id, ownerID, name, description string, filters models.SmartFilter) (models.SmartCollection, error) {
+	raw, err := json.Marshal(filters)
+	if err != nil {
+		return models.SmartCollection{}, err
+	}
+	return scanSmartCollection(r.pool.QueryRow(ctx, `
+		UPDATE smart_collections
+		SET name = $1, description = $2, filters = $3, updated_at = NOW()
+		WHERE id = $4 AND owner_id = $5
+		RETURNING `+smartCollectionColumns+`
+	`, name, description, raw, id, ownerID))
+}
+
+func (r *SmartCollectionRepository) Delete(ctx context.Context, id, ownerID string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM smart_collections WHERE id = $1 AND owner_id = $2`, id, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
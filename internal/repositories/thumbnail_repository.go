@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ThumbnailRepository struct{ pool *pgxpool.Pool }
+
+func NewThumbnailRepository(pool *pgxpool.Pool) *ThumbnailRepository {
+	return &ThumbnailRepository{pool: pool}
+}
+
+// ThumbnailSource is a link's preview image and the image its current
+// thumbnail was made from (or last tried).
+type ThumbnailSource struct {
+	LinkID   string
+	OwnerID  string
+	ImageURL string
+	Source   string
+}
+
+// Pending reports whether the link's image_url has not been tried yet.
+func (t ThumbnailSource) Pending() bool {
+	return t.ImageURL != "" && t.ImageURL != t.Source
+}
+
+func (r *ThumbnailRepository) Source(ctx context.Context, linkID, ownerID string) (ThumbnailSource, error) {
+	var s ThumbnailSource
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, owner_id, image_url, thumbnail_source
+		FROM links
+		WHERE id = $1 AND owner_id = $2
+	`, linkID, ownerID).Scan(&s.LinkID, &s.OwnerID, &s.ImageURL, &s.Source)
+	return s, err
+}
+
+// Pending lists links whose image_url has no thumbnail attempt yet, in id
+// order after afterID (a UUID; the nil UUID starts from the beginning).
+func (r *ThumbnailRepository) Pending(ctx context.Context, afterID string, limit int) ([]ThumbnailSource, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, owner_id, image_url, thumbnail_source
+		FROM links
+		WHERE image_url <> '' AND thumbnail_source <> image_url AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ThumbnailSource
+	for rows.Next() {
+		var s ThumbnailSource
+		if err := rows.Scan(&s.LinkID, &s.OwnerID, &s.ImageURL, &s.Source); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// SetLinkThumbnail records the thumbnail made from imageURL; an empty
+// thumbnailURL records a failed attempt. Returns pgx.ErrNoRows when the
+// link's image_url has changed since, so the newer image wins.
+func (r *ThumbnailRepository) SetLinkThumbnail(ctx context.Context, linkID, imageURL, thumbnailURL string) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE links SET thumbnail_url = $3, thumbnail_source = $2, updated_at = NOW()
+		WHERE id = $1 AND image_url = $2
+	`, linkID, imageURL, thumbnailURL)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+type DomainService struct{ repo *repositories.DomainRepository }
+
+func NewDomainService(repo *repositories.DomainRepository) *DomainService {
+	return &DomainService{repo: repo}
+}
+
+func (s *DomainService) List(ctx context.Context, ownerID, sortBy string) ([]repositories.DomainStats, error) {
+	return s.repo.List(ctx, ownerID, sortBy)
+}
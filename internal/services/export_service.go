@@ -0,0 +1,203 @@
+package services
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robstave/link-manager/internal/platform/bookmarks"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+// ExportService writes a user's links in formats other tools import.
+type ExportService struct {
+	links *LinkService
+	icons *IconService
+}
+
+func NewExportService(links *LinkService, icons *IconService) *ExportService {
+	return &ExportService{links: links, icons: icons}
+}
+
+// WriteBookmarksHTML writes the links matching f as a Netscape bookmarks.html:
+// one top-level folder per project, holding a subfolder per category. A
+// category name containing " / " is split into nested folders, which is how
+// ImportBookmarksHTML names categories for nested folders. Stored icons are
+// embedded as ICON data: URIs, remote ones written as ICON_URI.
+func (s *ExportService) WriteBookmarksHTML(ctx context.Context, ownerID string, f repositories.LinkFilters, w io.Writer) error {
+	items, err := s.links.Export(ctx, ownerID, f)
+	if err != nil {
+		return err
+	}
+	// Group by project and category, keeping newest first within each.
+	slices.SortStableFunc(items, func(a, b repositories.LinkWithMeta) int {
+		return cmp.Or(
+			cmp.Compare(strings.ToLower(a.ProjectName), strings.ToLower(b.ProjectName)),
+			cmp.Compare(strings.ToLower(a.CategoryName), strings.ToLower(b.CategoryName)),
+		)
+	})
+
+	icons := map[string]string{}
+	out := make([]bookmarks.Bookmark, 0, len(items))
+	for _, it := range items {
+		title := it.Title
+		if title == "" {
+			title = it.URL
+		}
+		folders := []string{it.ProjectName}
+		if it.CategoryName != "" {
+			folders = append(folders, strings.Split(it.CategoryName, " / ")...)
+		}
+		created := it.CreatedAt
+		b := bookmarks.Bookmark{
+			URL:         it.URL,
+			Title:       title,
+			Description: it.Description,
+			Folders:     folders,
+			Tags:        it.Tags,
+			AddedAt:     &created,
+		}
+		if hash, ok := strings.CutPrefix(it.IconURL, IconPathPrefix); ok {
+			b.Icon = s.iconDataURI(ctx, hash, icons)
+		} else if strings.HasPrefix(it.IconURL, "http://") || strings.HasPrefix(it.IconURL, "https://") {
+			b.IconURI = it.IconURL
+		}
+		out = append(out, b)
+	}
+	return bookmarks.Write(w, out)
+}
+
+// iconDataURI returns a stored icon as a data: URI, caching by hash since
+// many links share an icon. A missing icon is left out rather than failing
+// the export.
+func (s *ExportService) iconDataURI(ctx context.Context, hash string, cache map[string]string) string {
+	if uri, ok := cache[hash]; ok {
+		return uri
+	}
+	uri := ""
+	icon, err := s.icons.Get(ctx, hash)
+	if err == nil {
+		uri = "data:" + icon.ContentType + ";base64," + base64.StdEncoding.EncodeToString(icon.Data)
+	} else {
+		slog.Warn("export: stored icon unavailable", "hash", hash, "error", err)
+	}
+	cache[hash] = uri
+	return uri
+}
+
+// WriteObsidianZip writes the links matching f as a zip of Markdown notes
+// for an Obsidian vault: Project/Category/Title.md, with the category split
+// into nested folders like WriteBookmarksHTML. Each note has YAML
+// frontmatter and a section per non-empty text field.
+func (s *ExportService) WriteObsidianZip(ctx context.Context, ownerID string, f repositories.LinkFilters, w io.Writer) error {
+	items, err := s.links.Export(ctx, ownerID, f)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	used := map[string]bool{}
+	for _, it := range items {
+		dir := []string{noteName(it.ProjectName, "Project")}
+		if it.CategoryName != "" {
+			for _, name := range strings.Split(it.CategoryName, " / ") {
+				dir = append(dir, noteName(name, "Category"))
+			}
+		}
+		base := path.Join(append(dir, noteName(it.Title, it.Domain, it.ID))...)
+		name := base + ".md"
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = base + " (" + strconv.Itoa(n) + ").md"
+		}
+		used[strings.ToLower(name)] = true
+
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: it.UpdatedAt})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, obsidianNote(it)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// obsidianNote renders one link. Frontmatter strings are written as YAML
+// double-quoted scalars, whose escapes Go's quoting produces.
+func obsidianNote(it repositories.LinkWithMeta) string {
+	var b strings.Builder
+	quoted := make([]string, len(it.Tags))
+	for i, tag := range it.Tags {
+		quoted[i] = strconv.Quote(tag)
+	}
+	b.WriteString("---\n")
+	b.WriteString("id: " + it.ID + "\n")
+	b.WriteString("url: " + strconv.Quote(it.URL) + "\n")
+	b.WriteString("project: " + strconv.Quote(it.ProjectName) + "\n")
+	b.WriteString("category: " + strconv.Quote(it.CategoryName) + "\n")
+	b.WriteString("tags: [" + strings.Join(quoted, ", ") + "]\n")
+	b.WriteString("stars: " + strconv.Itoa(it.Stars) + "\n")
+	b.WriteString("cart: " + strconv.FormatBool(it.Cart) + "\n")
+	b.WriteString("created_at: " + strconv.Quote(it.CreatedAt.UTC().Format(time.RFC3339)) + "\n")
+	b.WriteString("---\n\n")
+	b.WriteString("# " + strings.Join(strings.Fields(cmp.Or(it.Title, it.URL)), " ") + "\n")
+	for _, section := range []struct{ heading, text string }{
+		{"Description", it.Description},
+		{"User Notes", it.UserNotes},
+		{"Generated Notes", it.GeneratedNotes},
+	} {
+		if text := strings.TrimSpace(section.text); text != "" {
+			b.WriteString("\n## " + section.heading + "\n\n" + text + "\n")
+		}
+	}
+	return b.String()
+}
+
+// maxNoteName caps a file or folder name, in runes, leaving room for a
+// " (2)" suffix and the extension within common 255-byte limits.
+const maxNoteName = 100
+
+// noteName makes the first non-empty candidate safe as a file or folder
+// name on every OS and as an Obsidian link target: path separators and
+// characters Windows or Obsidian reject become spaces, runs of spaces
+// collapse, and leading dots (hidden files) and trailing dots are trimmed.
+func noteName(candidates ...string) string {
+	for _, c := range candidates {
+		name := strings.Map(func(r rune) rune {
+			if r < 0x20 || r == 0x7f || strings.ContainsRune(`/\:*?"<>|#^[]`, r) {
+				return ' '
+			}
+			return r
+		}, c)
+		name = strings.Trim(strings.Join(strings.Fields(name), " "), ". ")
+		if runes := []rune(name); len(runes) > maxNoteName {
+			name = strings.TrimRight(string(runes[:maxNoteName]), ". ")
+		}
+		if name == "" {
+			continue
+		}
+		if reservedNoteName(name) {
+			name = "_" + name
+		}
+		return name
+	}
+	return "Untitled"
+}
+
+// reservedNoteName reports Windows device names, which are invalid as file
+// names with any extension.
+func reservedNoteName(name string) bool {
+	stem, _, _ := strings.Cut(strings.ToUpper(name), ".")
+	switch stem {
+	case "CON", "PRN", "AUX", "NUL":
+		return true
+	}
+	return len(stem) == 4 && (strings.HasPrefix(stem, "COM") || strings.HasPrefix(stem, "LPT")) && stem[3] >= '1' && stem[3] <= '9'
+}
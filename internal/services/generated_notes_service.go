@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/platform/summarizer"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+var ErrInvalidNotesSize = errors.New("size must be one of tiny, short, medium, long")
+
+const (
+	NotesStatusReady      = "ready"
+	NotesStatusProcessing = "processing"
+)
+
+type GeneratedNotesService struct {
+	repo       *repositories.GeneratedNotesRepository
+	links      *LinkService
+	metaSvc    *MetadataService
+	summarizer summarizer.Summarizer
+	jobs       *JobQueue
+}
+
+func NewGeneratedNotesService(repo *repositories.GeneratedNotesRepository, links *LinkService, metaSvc *MetadataService, sum summarizer.Summarizer, jobs *JobQueue) *GeneratedNotesService {
+	return &GeneratedNotesService{repo: repo, links: links, metaSvc: metaSvc, summarizer: sum, jobs: jobs}
+}
+
+type GenerateResult struct {
+	Status string
+	Notes  string
+	Size   string
+	JobID  string
+}
+
+type GenerateNotesPayload struct {
+	LinkID string `json:"link_id"`
+	Size   string `json:"size"`
+}
+
+// Generate returns cached notes for the size immediately. Otherwise it queues
+// a generate_notes job and reports NotesStatusProcessing with the job ID;
+// callers poll the job, or call Generate again, until the notes are ready.
+func (s *GeneratedNotesService) Generate(ctx context.Context, ownerID, linkID, size string, force bool) (GenerateResult, error) {
+	if !summarizer.ValidSize(size) {
+		return GenerateResult{}, ErrInvalidNotesSize
+	}
+	link, err := s.links.Get(ctx, linkID, ownerID)
+	if err != nil {
+		return GenerateResult{}, err
+	}
+
+	if !force {
+		notes, err := s.repo.Cached(ctx, linkID, size, link.URL)
+		if err == nil {
+			if link.GeneratedNotes != notes || link.GeneratedNotesSize != size {
+				if err := s.repo.Apply(ctx, linkID, notes, size); err != nil {
+					return GenerateResult{}, err
+				}
+				s.links.refreshEmbedding(ctx, linkID)
+			}
+			return GenerateResult{Status: NotesStatusReady, Notes: notes, Size: size}, nil
+		}
+		if !IsNotFound(err) {
+			return GenerateResult{}, err
+		}
+	}
+
+	job, err := s.jobs.Enqueue(ctx, ownerID, JobGenerateNotes, GenerateNotesPayload{LinkID: linkID, Size: size}, EnqueueOptions{DedupeKey: linkID + "/" + size, MaxAttempts: 3})
+	if err != nil {
+		return GenerateResult{}, err
+	}
+	return GenerateResult{Status: NotesStatusProcessing, Size: size, JobID: job.ID}, nil
+}
+
+// HandleGenerateNotesJob is the JobGenerateNotes handler.
+func (s *GeneratedNotesService) HandleGenerateNotesJob(ctx context.Context, job models.Job) (any, error) {
+	var p GenerateNotesPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %v: %w", err, ErrPermanent)
+	}
+	if job.OwnerID == nil {
+		return nil, fmt.Errorf("job has no owner: %w", ErrPermanent)
+	}
+	link, err := s.links.Get(ctx, p.LinkID, *job.OwnerID)
+	if IsNotFound(err) {
+		return nil, fmt.Errorf("link %s no longer exists: %w", p.LinkID, ErrPermanent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, s.run(ctx, link, p.Size)
+}
+
+func (s *GeneratedNotesService) run(ctx context.Context, link repositories.LinkWithMeta, size string) error {
+	saved := strings.Join([]string{link.Title, link.Description, link.UserNotes}, ". ")
+	text, err := s.metaSvc.FetchPageText(ctx, link.URL)
+	if err != nil {
+		slog.Warn("notes: page fetch failed, summarizing saved fields", "linkID", link.ID, "error", err)
+	}
+	if strings.TrimSpace(text) == "" {
+		text = saved
+	}
+
+	in := summarizer.Input{URL: link.URL, Title: link.Title, Text: text, Size: size}
+	notes, by, err := s.summarizer.Summarize(ctx, in)
+	if errors.Is(err, summarizer.ErrNoText) && text != saved {
+		// The page text had no usable sentences, e.g. only headings or one
+		// long unpunctuated block.
+		slog.Info("notes: page text unusable, summarizing saved fields", "linkID", link.ID)
+		in.Text = saved
+		notes, by, err = s.summarizer.Summarize(ctx, in)
+	}
+	if errors.Is(err, summarizer.ErrNoText) {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Store(ctx, link.ID, size, link.URL, notes, by); err != nil {
+		return err
+	}
+	if err := s.repo.Apply(ctx, link.ID, notes, size); err != nil {
+		return err
+	}
+	s.links.refreshEmbedding(ctx, link.ID)
+	slog.Info("notes: generated", "linkID", link.ID, "size", size, "summarizer", by)
+	return nil
+}
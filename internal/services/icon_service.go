@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/imaging"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+// IconPathPrefix is where stored icons are served; links pointing here no
+// longer hotlink a third-party host.
+const IconPathPrefix = "/api/v1/icons/"
+
+const (
+	iconSize     = 64
+	maxIconBytes = 1 << 20
+	maxSVGBytes  = 256 << 10
+	// maxIconPixels refuses icons whose header declares a size that would
+	// take too much memory to decode, however small the file.
+	maxIconPixels = 16_000_000
+)
+
+var (
+	ErrIconNotFound        = errors.New("no usable icon found")
+	ErrInvalidIconOverride = errors.New("icon override needs a domain and an http(s) icon_url")
+)
+
+type IconService struct {
+	repo    *repositories.IconRepository
+	links   *repositories.LinkRepository
+	metaSvc *MetadataService
+	client  *http.Client
+}
+
+func NewIconService(repo *repositories.IconRepository, links *repositories.LinkRepository, metaSvc *MetadataService, fetcher *fetch.Client) *IconService {
+	return &IconService{repo: repo, links: links, metaSvc: metaSvc, client: fetcher.HTTPClient(10 * time.Second)}
+}
+
+// FetchForLink downloads the link's icon, stores it and points the link's
+// icon_url at the stored copy. Sources, in order: an admin override for the
+// link's domain, the icon_url found by metadata enrichment, the page's
+// declared icon, then /favicon.ico. An icon_url the user set is the only
+// source tried, so their choice is stored but never replaced.
+func (s *IconService) FetchForLink(ctx context.Context, ownerID, linkID string) (string, error) {
+	link, err := s.links.Get(ctx, linkID, ownerID)
+	if err != nil {
+		return "", err
+	}
+	return s.fetchFor(ctx, link)
+}
+
+func (s *IconService) fetchFor(ctx context.Context, link repositories.LinkWithMeta) (string, error) {
+	linkID, current := link.ID, link.IconURL
+	remote := strings.HasPrefix(current, "http://") || strings.HasPrefix(current, "https://")
+	var candidates []string
+	if slices.Contains(link.UserEditedFields, EditedIconURL) {
+		if remote {
+			candidates = append(candidates, current)
+		}
+	} else {
+		if override, err := s.repo.Override(ctx, link.Domain); err == nil {
+			candidates = append(candidates, override)
+		} else if !IsNotFound(err) {
+			return "", err
+		}
+		if remote {
+			candidates = append(candidates, current)
+		}
+		if meta, err := s.metaSvc.FetchPageMeta(ctx, link.URL); err == nil && meta.IconURL != "" {
+			candidates = append(candidates, meta.IconURL)
+		}
+		if u, err := url.Parse(link.URL); err == nil && u.Host != "" {
+			candidates = append(candidates, u.Scheme+"://"+u.Host+"/favicon.ico")
+		}
+	}
+
+	tried := map[string]bool{}
+	for _, candidate := range candidates {
+		if tried[candidate] {
+			continue
+		}
+		tried[candidate] = true
+		hash, err := s.store(ctx, candidate)
+		if err != nil {
+			slog.Warn("icons: candidate failed", "linkID", linkID, "iconURL", candidate, "error", err)
+			continue
+		}
+		iconURL := IconPathPrefix + hash
+		if iconURL != current {
+			if err := s.repo.SetLinkIcon(ctx, linkID, current, iconURL); err != nil {
+				return "", err
+			}
+		}
+		slog.Info("icons: stored link icon", "linkID", linkID, "source", candidate, "hash", hash)
+		return iconURL, nil
+	}
+	return "", ErrIconNotFound
+}
+
+// store downloads one icon and saves it.
+func (s *IconService) store(ctx context.Context, iconURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIconBytes+1))
+	if err != nil {
+		return "", err
+	}
+	return s.save(ctx, data, resp.Header.Get("Content-Type"), iconURL)
+}
+
+// StoreData validates, normalises and stores icon bytes that came from
+// somewhere other than a download, such as a data: URI in an imported
+// bookmark, and returns the stored icon's URL.
+func (s *IconService) StoreData(ctx context.Context, data []byte, contentType, source string) (string, error) {
+	hash, err := s.save(ctx, data, contentType, source)
+	if err != nil {
+		return "", err
+	}
+	return IconPathPrefix + hash, nil
+}
+
+// save validates and stores one icon and returns its hash. Raster icons are
+// re-encoded as PNG no larger than iconSize; SVGs are kept as sent.
+func (s *IconService) save(ctx context.Context, data []byte, contentType, source string) (string, error) {
+	if len(data) > maxIconBytes {
+		return "", fmt.Errorf("icon larger than %d bytes", maxIconBytes)
+	}
+
+	icon := repositories.Icon{SourceURL: source}
+	if isSVG(data, contentType) {
+		if len(data) > maxSVGBytes {
+			return "", fmt.Errorf("svg icon larger than %d bytes", maxSVGBytes)
+		}
+		icon.ContentType = "image/svg+xml"
+		icon.Data = data
+	} else {
+		w, h, _, err := imaging.Dimensions(data)
+		if err != nil {
+			return "", err
+		}
+		if w == 0 || h == 0 {
+			return "", errors.New("empty image")
+		}
+		if w*h > maxIconPixels {
+			return "", fmt.Errorf("icon is %dx%d", w, h)
+		}
+		img, _, err := imaging.Decode(data)
+		if err != nil {
+			return "", err
+		}
+		fitted := imaging.Fit(img, iconSize)
+		encoded, err := imaging.EncodePNG(fitted)
+		if err != nil {
+			return "", err
+		}
+		icon.ContentType = "image/png"
+		icon.Data = encoded
+		icon.Width, icon.Height = fitted.Bounds().Dx(), fitted.Bounds().Dy()
+	}
+	sum := sha256.Sum256(icon.Data)
+	icon.Hash = hex.EncodeToString(sum[:])
+	if err := s.repo.Put(ctx, icon); err != nil {
+		return "", err
+	}
+	return icon.Hash, nil
+}
+
+func isSVG(data []byte, contentType string) bool {
+	if strings.HasPrefix(contentType, "image/svg+xml") {
+		return true
+	}
+	head := bytes.ToLower(data[:min(len(data), 512)])
+	return bytes.Contains(head, []byte("<svg"))
+}
+
+func (s *IconService) Get(ctx context.Context, hash string) (repositories.Icon, error) {
+	return s.repo.Get(ctx, hash)
+}
+
+func (s *IconService) ListOverrides(ctx context.Context) ([]repositories.IconOverride, error) {
+	return s.repo.ListOverrides(ctx)
+}
+
+func (s *IconService) PutOverride(ctx context.Context, domain, iconURL string) (repositories.IconOverride, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	u, err := url.Parse(strings.TrimSpace(iconURL))
+	if domain == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return repositories.IconOverride{}, ErrInvalidIconOverride
+	}
+	return s.repo.PutOverride(ctx, domain, u.String())
+}
+
+func (s *IconService) DeleteOverride(ctx context.Context, domain string) error {
+	return s.repo.DeleteOverride(ctx, strings.ToLower(domain))
+}
+
+type FetchIconPayload struct {
+	LinkID string `json:"link_id"`
+}
+
+// HandleFetchIconJob is the JobFetchIcon handler.
+func (s *IconService) HandleFetchIconJob(ctx context.Context, job models.Job) (any, error) {
+	var p FetchIconPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %v: %w", err, ErrPermanent)
+	}
+	if job.OwnerID == nil {
+		return nil, fmt.Errorf("job has no owner: %w", ErrPermanent)
+	}
+	link, err := s.links.Get(ctx, p.LinkID, *job.OwnerID)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(link.UserEditedFields, EditedIconURL) && !strings.HasPrefix(link.IconURL, "http://") && !strings.HasPrefix(link.IconURL, "https://") {
+		// The user's own icon, e.g. from an imported bookmark, is already
+		// stored, or they cleared it; either way there is nothing to fetch.
+		return nil, nil
+	}
+	iconURL, err := s.fetchFor(ctx, link)
+	if errors.Is(err, ErrIconNotFound) {
+		// Nothing to store; the link keeps whatever it had.
+		return nil, fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	if IsNotFound(err) {
+		// icon_url changed underneath us; the newer value wins.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"icon_url": iconURL}, nil
+}
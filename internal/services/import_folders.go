@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+// importFolders maps imported folder paths onto the owner's projects and
+// categories, creating missing ones (or, in a dry run, only noting them).
+type importFolders struct {
+	ownerID    string
+	projects   *repositories.ProjectRepository
+	categories *repositories.CategoryRepository
+
+	fixed    *importProject // ImportOptions.ProjectID
+	fallback *importProject // the default project
+	byName   map[string]*importProject
+
+	newProjects   []string
+	newCategories []string
+}
+
+type importProject struct {
+	id, name   string
+	categories map[string]importCategory // nil until loaded
+	unsorted   importCategory
+}
+
+type importCategory struct{ id, name string }
+
+// importPlace is where an item goes. IDs are empty in a dry run for
+// projects and categories that don't exist yet.
+type importPlace struct {
+	projectID, project   string
+	categoryID, category string
+}
+
+// newProjectCategory is the default category every new project gets.
+const newProjectCategory = "Unsorted"
+
+func newImportFolders(ctx context.Context, ownerID string, projects *repositories.ProjectRepository, categories *repositories.CategoryRepository, projectID string) (*importFolders, error) {
+	f := &importFolders{ownerID: ownerID, projects: projects, categories: categories, byName: map[string]*importProject{}}
+	list, err := projects.List(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		ip := &importProject{id: p.ID, name: p.Name}
+		f.byName[folderKey(p.Name)] = ip
+		if p.IsDefault {
+			f.fallback = ip
+		}
+		if p.ID == projectID {
+			f.fixed = ip
+		}
+	}
+	if projectID != "" && f.fixed == nil {
+		return nil, pgx.ErrNoRows
+	}
+	if f.fallback == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return f, nil
+}
+
+func (f *importFolders) resolve(ctx context.Context, path []string, dryRun bool) (importPlace, error) {
+	var p *importProject
+	var categoryName string
+	switch {
+	case f.fixed != nil:
+		p, categoryName = f.fixed, strings.Join(path, " / ")
+	case len(path) == 0:
+		p = f.fallback
+	default:
+		var err error
+		if p, err = f.project(ctx, path[0], dryRun); err != nil {
+			return importPlace{}, err
+		}
+		categoryName = strings.Join(path[1:], " / ")
+	}
+	if err := f.loadCategories(ctx, p); err != nil {
+		return importPlace{}, err
+	}
+	c := p.unsorted
+	if categoryName != "" {
+		var err error
+		if c, err = f.category(ctx, p, categoryName, dryRun); err != nil {
+			return importPlace{}, err
+		}
+	}
+	return importPlace{projectID: p.id, project: p.name, categoryID: c.id, category: c.name}, nil
+}
+
+func (f *importFolders) project(ctx context.Context, name string, dryRun bool) (*importProject, error) {
+	if p, ok := f.byName[folderKey(name)]; ok {
+		return p, nil
+	}
+	p := &importProject{name: name, categories: map[string]importCategory{}, unsorted: importCategory{name: newProjectCategory}}
+	if !dryRun {
+		created, err := f.projects.Create(ctx, f.ownerID, name, "")
+		if err != nil {
+			return nil, err
+		}
+		// Load its default category with the real id.
+		p = &importProject{id: created.ID, name: created.Name}
+	}
+	f.byName[folderKey(name)] = p
+	f.newProjects = append(f.newProjects, name)
+	return p, nil
+}
+
+func (f *importFolders) loadCategories(ctx context.Context, p *importProject) error {
+	if p.categories != nil {
+		return nil
+	}
+	list, err := f.categories.List(ctx, p.id)
+	if err != nil {
+		return err
+	}
+	p.categories = map[string]importCategory{}
+	for _, c := range list {
+		ic := importCategory{id: c.ID, name: c.Name}
+		p.categories[folderKey(c.Name)] = ic
+		if c.IsDefault {
+			p.unsorted = ic
+		}
+	}
+	return nil
+}
+
+func (f *importFolders) category(ctx context.Context, p *importProject, name string, dryRun bool) (importCategory, error) {
+	if c, ok := p.categories[folderKey(name)]; ok {
+		return c, nil
+	}
+	c := importCategory{name: name}
+	if !dryRun {
+		created, err := f.categories.Create(ctx, p.id, name)
+		if err != nil {
+			return importCategory{}, err
+		}
+		c.id = created.ID
+	}
+	p.categories[folderKey(name)] = c
+	f.newCategories = append(f.newCategories, p.name+" / "+name)
+	return c, nil
+}
+
+func (f *importFolders) created() (projects, categories []string) {
+	projects, categories = f.newProjects, f.newCategories
+	if projects == nil {
+		projects = []string{}
+	}
+	if categories == nil {
+		categories = []string{}
+	}
+	return projects, categories
+}
+
+func folderKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
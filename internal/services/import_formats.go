@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/robstave/link-manager/internal/platform/bookmarks"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// importFavoriteStars is the rating given to links a source marks as
+// favorites.
+const importFavoriteStars = 5
+
+// Importer reads one export format into import items. Parse errors are
+// reported as ErrInvalidImportFile by ImportFile.
+type Importer interface {
+	Name() string
+	Parse(r io.Reader) ([]ImportItem, error)
+}
+
+func defaultImporters() map[string]Importer {
+	importers := map[string]Importer{}
+	for _, imp := range []Importer{netscapeImporter{}, pocketImporter{}, raindropImporter{}, pinboardImporter{}, linkdingImporter{}} {
+		importers[imp.Name()] = imp
+	}
+	return importers
+}
+
+// netscapeImporter reads a browser's bookmarks.html. ADD_DATE becomes the
+// link's created_at, TAGS its tags, ICON (a data: URI) its stored icon and
+// ICON_URI its remote icon; a <DD> becomes the description.
+type netscapeImporter struct{}
+
+func (netscapeImporter) Name() string { return "netscape" }
+
+func (netscapeImporter) Parse(r io.Reader) ([]ImportItem, error) {
+	parsed, err := bookmarks.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ImportItem, len(parsed))
+	for i, b := range parsed {
+		items[i] = ImportItem{
+			URL:         b.URL,
+			Title:       b.Title,
+			Description: b.Description,
+			Folders:     b.Folders,
+			Tags:        b.Tags,
+			AddedAt:     b.AddedAt,
+			Icon:        b.Icon,
+			IconURL:     b.IconURI,
+		}
+	}
+	return items, nil
+}
+
+// pocketImporter reads Pocket's export: the CSV (title, url, time_added,
+// tags, status; tags separated by "|") or the older ril_export.html, a list
+// of <a href time_added tags> per section. Pocket has no folders, notes or
+// favorites in either.
+type pocketImporter struct{}
+
+func (pocketImporter) Name() string { return "pocket" }
+
+func (pocketImporter) Parse(r io.Reader) ([]ImportItem, error) {
+	br := bufio.NewReader(r)
+	if firstByte(br) == '<' {
+		return parsePocketHTML(br)
+	}
+	rows, err := readCSV(br, "url")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ImportItem, len(rows))
+	for i, row := range rows {
+		items[i] = ImportItem{
+			URL:     row["url"],
+			Title:   row["title"],
+			Tags:    splitTags(row["tags"], "|"),
+			AddedAt: unixTime(row["time_added"]),
+		}
+	}
+	return items, nil
+}
+
+func parsePocketHTML(r io.Reader) ([]ImportItem, error) {
+	z := html.NewTokenizer(r)
+	var items []ImportItem
+	var current *ImportItem
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			if items == nil {
+				return nil, errors.New("no links in Pocket export")
+			}
+			return items, nil
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			if atom.Lookup(name) != atom.A {
+				continue
+			}
+			item := ImportItem{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "href":
+					item.URL = strings.TrimSpace(string(val))
+				case "time_added":
+					item.AddedAt = unixTime(string(val))
+				case "tags":
+					item.Tags = splitTags(string(val), ",")
+				}
+			}
+			items = append(items, item)
+			current = &items[len(items)-1]
+		case html.TextToken:
+			if current != nil {
+				current.Title += string(z.Text())
+			}
+		case html.EndTagToken:
+			if current != nil {
+				current.Title = strings.Join(strings.Fields(current.Title), " ")
+				current = nil
+			}
+		}
+	}
+}
+
+// raindropImporter reads Raindrop.io's CSV export. The collection ("folder",
+// nested ones as "Parent/Child") maps onto folders, "note" onto user notes,
+// "excerpt" onto the description and "favorite" onto stars. Links in
+// Raindrop's Unsorted collection have no folder.
+type raindropImporter struct{}
+
+func (raindropImporter) Name() string { return "raindrop" }
+
+func (raindropImporter) Parse(r io.Reader) ([]ImportItem, error) {
+	rows, err := readCSV(r, "url")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ImportItem, len(rows))
+	for i, row := range rows {
+		item := ImportItem{
+			URL:         row["url"],
+			Title:       row["title"],
+			Description: row["excerpt"],
+			UserNotes:   row["note"],
+			Tags:        splitTags(row["tags"], ","),
+			AddedAt:     isoTime(row["created"]),
+		}
+		if folder := strings.TrimSpace(row["folder"]); folder != "" && !strings.EqualFold(folder, "Unsorted") {
+			for _, name := range strings.Split(folder, "/") {
+				if name = strings.TrimSpace(name); name != "" {
+					item.Folders = append(item.Folders, name)
+				}
+			}
+		}
+		if row["favorite"] == "true" {
+			item.Stars = importFavoriteStars
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// pinboardImporter reads Pinboard's JSON export (/v1/posts/all?format=json).
+// "description" is the title, "extended" the user's notes and "tags" a
+// space-separated list. Pinboard has no folders or favorites.
+type pinboardImporter struct{}
+
+func (pinboardImporter) Name() string { return "pinboard" }
+
+type pinboardPost struct {
+	Href        string `json:"href"`
+	Description string `json:"description"`
+	Extended    string `json:"extended"`
+	Time        string `json:"time"`
+	Tags        string `json:"tags"`
+}
+
+func (pinboardImporter) Parse(r io.Reader) ([]ImportItem, error) {
+	var posts []pinboardPost
+	if err := json.NewDecoder(r).Decode(&posts); err != nil {
+		return nil, err
+	}
+	items := make([]ImportItem, len(posts))
+	for i, p := range posts {
+		items[i] = ImportItem{
+			URL:       p.Href,
+			Title:     p.Description,
+			UserNotes: p.Extended,
+			Tags:      splitTags(p.Tags, " "),
+			AddedAt:   isoTime(p.Time),
+		}
+	}
+	return items, nil
+}
+
+// linkdingImporter reads linkding's HTML export, a bookmarks.html whose <DD>
+// holds the description followed by "[linkding-notes]...[/linkding-notes]",
+// or its JSON (a /api/bookmarks/ response, or just its results array).
+// linkding organizes with tags only, so there are no folders or favorites.
+type linkdingImporter struct{}
+
+func (linkdingImporter) Name() string { return "linkding" }
+
+type linkdingBookmark struct {
+	URL                string    `json:"url"`
+	Title              string    `json:"title"`
+	Description        string    `json:"description"`
+	Notes              string    `json:"notes"`
+	WebsiteTitle       string    `json:"website_title"`
+	WebsiteDescription string    `json:"website_description"`
+	TagNames           []string  `json:"tag_names"`
+	DateAdded          time.Time `json:"date_added"`
+}
+
+func (linkdingImporter) Parse(r io.Reader) ([]ImportItem, error) {
+	br := bufio.NewReader(r)
+	switch firstByte(br) {
+	case '<':
+		items, err := netscapeImporter{}.Parse(br)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			desc, notes, ok := strings.Cut(items[i].Description, "[linkding-notes]")
+			if ok {
+				items[i].Description = strings.TrimSpace(desc)
+				items[i].UserNotes = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(notes), "[/linkding-notes]"))
+			}
+		}
+		return items, nil
+	case '[':
+		var list []linkdingBookmark
+		if err := json.NewDecoder(br).Decode(&list); err != nil {
+			return nil, err
+		}
+		return linkdingItems(list), nil
+	default:
+		var page struct {
+			Results []linkdingBookmark `json:"results"`
+		}
+		if err := json.NewDecoder(br).Decode(&page); err != nil {
+			return nil, err
+		}
+		return linkdingItems(page.Results), nil
+	}
+}
+
+func linkdingItems(list []linkdingBookmark) []ImportItem {
+	items := make([]ImportItem, len(list))
+	for i, b := range list {
+		item := ImportItem{
+			URL:         b.URL,
+			Title:       cmp.Or(b.Title, b.WebsiteTitle),
+			Description: cmp.Or(b.Description, b.WebsiteDescription),
+			UserNotes:   b.Notes,
+			Tags:        b.TagNames,
+		}
+		if !b.DateAdded.IsZero() {
+			added := b.DateAdded.UTC()
+			item.AddedAt = &added
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// firstByte skips a byte order mark and leading white space and peeks at
+// the first byte after them, to tell formats apart.
+func firstByte(br *bufio.Reader) byte {
+	if bom, _ := br.Peek(3); string(bom) == "\ufeff" {
+		br.Discard(3)
+	}
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0
+		}
+		if !unicode.IsSpace(rune(c)) {
+			br.UnreadByte()
+			return c
+		}
+	}
+}
+
+// readCSV reads a CSV with a header row into one map per row, keyed by
+// lower-cased column name. required must be among the columns.
+func readCSV(r io.Reader, required string) ([]map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	}
+	if !slices.Contains(header, required) {
+		return nil, errors.New("missing " + required + " column")
+	}
+	var rows []map[string]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, v := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func splitTags(s, sep string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, sep) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func unixTime(v string) *time.Time {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	t := time.Unix(n, 0).UTC()
+	return &t
+}
+
+func isoTime(v string) *time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+	if err != nil {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
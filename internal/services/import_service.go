@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+// Import outcomes, per item.
+const (
+	ImportCreated     = "created"
+	ImportWouldCreate = "would_create"
+	ImportDuplicate   = "duplicate"
+	ImportInvalid     = "invalid"
+	ImportFailed      = "failed"
+)
+
+var (
+	ErrInvalidImportFile   = errors.New("invalid import file")
+	ErrUnknownImportFormat = errors.New("unknown import format")
+)
+
+// ImportItem is one link read from an export file, whatever its format.
+// Folders is the source's folder path, outermost first; see Import for how
+// it maps onto projects and categories.
+type ImportItem struct {
+	URL         string
+	Title       string
+	Description string
+	UserNotes   string
+	Folders     []string
+	Tags        []string
+	Stars       int
+	AddedAt     *time.Time
+	// Icon is a data: URI to store; IconURL a remote icon to fetch later.
+	Icon    string
+	IconURL string
+}
+
+type ImportOptions struct {
+	// ProjectID, when set, puts every item in that project and maps the
+	// whole folder path onto its category.
+	ProjectID string
+	// DryRun reports what would happen without writing anything.
+	DryRun bool
+}
+
+// ImportReport is the result of an import or dry run. NewProjects and
+// NewCategories ("Project / Category") list what was, or would be, created.
+type ImportReport struct {
+	DryRun        bool            `json:"dry_run"`
+	Total         int             `json:"total"`
+	Created       int             `json:"created"`
+	Duplicates    int             `json:"duplicates"`
+	Invalid       int             `json:"invalid"`
+	Failed        int             `json:"failed"`
+	NewProjects   []string        `json:"new_projects"`
+	NewCategories []string        `json:"new_categories"`
+	Items         []ImportOutcome `json:"items"`
+}
+
+// ImportOutcome reports one item. Project and Category are where it went, or
+// would go. LinkID is the created link, or for a duplicate the link it
+// duplicates (empty in a dry run when the original is earlier in the same
+// file).
+type ImportOutcome struct {
+	Index    int        `json:"index"`
+	URL      string     `json:"url"`
+	Title    string     `json:"title"`
+	Project  string     `json:"project,omitempty"`
+	Category string     `json:"category,omitempty"`
+	Tags     []string   `json:"tags"`
+	AddedAt  *time.Time `json:"added_at,omitempty"`
+	Status   string     `json:"status"`
+	LinkID   string     `json:"link_id,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+type ImportService struct {
+	links      *LinkService
+	linkRepo   *repositories.LinkRepository
+	projects   *repositories.ProjectRepository
+	categories *repositories.CategoryRepository
+	icons      *IconService
+	importers  map[string]Importer
+}
+
+// NewImportService returns a service reading the built-in formats; see
+// import_formats.go.
+func NewImportService(links *LinkService, linkRepo *repositories.LinkRepository, projects *repositories.ProjectRepository, categories *repositories.CategoryRepository, icons *IconService) *ImportService {
+	return &ImportService{links: links, linkRepo: linkRepo, projects: projects, categories: categories, icons: icons, importers: defaultImporters()}
+}
+
+// Formats lists the names ImportFile accepts, sorted.
+func (s *ImportService) Formats() []string {
+	return slices.Sorted(maps.Keys(s.importers))
+}
+
+// ImportFile parses an export file in the named format and imports it.
+func (s *ImportService) ImportFile(ctx context.Context, ownerID, format string, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	importer, ok := s.importers[format]
+	if !ok {
+		return ImportReport{}, fmt.Errorf("%w %q", ErrUnknownImportFormat, format)
+	}
+	items, err := importer.Parse(r)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("%w: %s: %w", ErrInvalidImportFile, importer.Name(), err)
+	}
+	return s.Import(ctx, ownerID, items, opts)
+}
+
+// ImportBookmarksHTML imports a Netscape bookmarks.html export.
+func (s *ImportService) ImportBookmarksHTML(ctx context.Context, ownerID string, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	return s.ImportFile(ctx, ownerID, "netscape", r, opts)
+}
+
+// Import creates links for items, skipping URLs the user already has (by
+// canonical URL) and repeats within the batch. Without opts.ProjectID the
+// first folder names the project and the rest, joined with " / ", the
+// category; items outside any folder go to the default project, and items
+// without a category folder to the project's default category. Projects and
+// categories are matched by name, case-insensitively, and created as needed.
+func (s *ImportService) Import(ctx context.Context, ownerID string, items []ImportItem, opts ImportOptions) (ImportReport, error) {
+	folders, err := newImportFolders(ctx, ownerID, s.projects, s.categories, opts.ProjectID)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	existing, err := s.linkRepo.URLs(ctx, ownerID)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	seen := make(map[string]string, len(existing))
+	for _, l := range existing {
+		seen[canonicalURL(l.URL)] = l.ID
+	}
+
+	report := ImportReport{DryRun: opts.DryRun, Total: len(items), NewProjects: []string{}, NewCategories: []string{}, Items: make([]ImportOutcome, 0, len(items))}
+	for i, item := range items {
+		out := ImportOutcome{Index: i, URL: item.URL, Title: item.Title, Tags: item.Tags, AddedAt: item.AddedAt}
+		if out.Tags == nil {
+			out.Tags = []string{}
+		}
+		normURL, err := importURL(item.URL)
+		if err != nil {
+			out.Status, out.Error = ImportInvalid, err.Error()
+			report.Invalid++
+			report.Items = append(report.Items, out)
+			continue
+		}
+		key := canonicalURL(normURL)
+		if linkID, dup := seen[key]; dup {
+			out.Status, out.LinkID = ImportDuplicate, linkID
+			report.Duplicates++
+			report.Items = append(report.Items, out)
+			continue
+		}
+		seen[key] = ""
+
+		place, err := folders.resolve(ctx, item.Folders, opts.DryRun)
+		if err == nil {
+			out.Project, out.Category = place.project, place.category
+		}
+		if err == nil && opts.DryRun {
+			out.Status = ImportWouldCreate
+			report.Created++
+			report.Items = append(report.Items, out)
+			continue
+		}
+		linkID := ""
+		if err == nil {
+			linkID, err = s.create(ctx, ownerID, place, normURL, item)
+		}
+		if err != nil {
+			slog.Error("import: create link failed", "url", normURL, "error", err)
+			out.Status, out.Error = ImportFailed, err.Error()
+			report.Failed++
+			report.Items = append(report.Items, out)
+			continue
+		}
+		seen[key] = linkID
+		out.Status, out.LinkID = ImportCreated, linkID
+		report.Created++
+		report.Items = append(report.Items, out)
+	}
+	report.NewProjects, report.NewCategories = folders.created()
+	slog.Info("import: finished", "ownerID", ownerID, "dryRun", opts.DryRun, "total", report.Total, "created", report.Created,
+		"duplicates", report.Duplicates, "invalid", report.Invalid, "failed", report.Failed)
+	return report, nil
+}
+
+func (s *ImportService) create(ctx context.Context, ownerID string, place importPlace, normURL string, item ImportItem) (string, error) {
+	iconURL := ""
+	if item.Icon != "" {
+		if data, contentType, ok := decodeDataURI(item.Icon); ok {
+			if stored, err := s.icons.StoreData(ctx, data, contentType, normURL); err == nil {
+				iconURL = stored
+			} else {
+				slog.Warn("import: bookmark icon unusable", "url", normURL, "error", err)
+			}
+		}
+	}
+	if iconURL == "" && (strings.HasPrefix(item.IconURL, "http://") || strings.HasPrefix(item.IconURL, "https://")) {
+		iconURL = item.IconURL
+	}
+	link, err := s.links.Create(ctx, ownerID, CreateLinkInput{
+		URL:         normURL,
+		Title:       item.Title,
+		Description: item.Description,
+		UserNotes:   item.UserNotes,
+		IconURL:     iconURL,
+		ProjectID:   place.projectID,
+		CategoryID:  place.categoryID,
+		Tags:        item.Tags,
+		Stars:       min(max(item.Stars, 0), 10),
+		CreatedAt:   item.AddedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return link.ID, nil
+}
+
+// importURL accepts absolute http(s) URLs only; bookmark files also hold
+// javascript: bookmarklets and browser-internal place: queries.
+func importURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("unsupported url %q", raw)
+	}
+	return raw, nil
+}
+
+// decodeDataURI decodes a base64 data: URI such as the ICON attribute.
+func decodeDataURI(uri string) ([]byte, string, bool) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return nil, "", false
+	}
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return nil, "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", false
+	}
+	return data, strings.TrimSuffix(meta, ";base64"), true
+}
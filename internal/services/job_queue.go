@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+// Job kinds.
+const (
+	JobEmbedLink      = "embed_link"
+	JobEnrichMetadata = "enrich_metadata"
+	JobGenerateNotes  = "generate_notes"
+	JobFetchIcon      = "fetch_icon"
+	JobFetchThumbnail = "fetch_thumbnail"
+	// JobBackfillThumbnails is a system job with no owner.
+	JobBackfillThumbnails = "backfill_thumbnails"
+)
+
+// ErrPermanent marks a job failure that retrying cannot fix; the job is
+// dead-lettered immediately. Wrap it with fmt.Errorf("...: %w", ErrPermanent).
+var ErrPermanent = errors.New("permanent job failure")
+
+// JobHandler runs one job. The returned value, if non-nil, is stored as the
+// job result.
+type JobHandler func(ctx context.Context, job models.Job) (any, error)
+
+type EnqueueOptions struct {
+	// DedupeKey collapses duplicate work: while a job with the same kind and
+	// key is queued, Enqueue returns it instead of adding another. One that is
+	// already running doesn't count, and the new job runs after it.
+	DedupeKey   string
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+// JobQueue runs durable background jobs stored in Postgres on worker
+// goroutines inside the API process.
+type JobQueue struct {
+	repo       *repositories.JobRepository
+	workerID   string
+	handlers   map[string]JobHandler
+	mu         sync.RWMutex
+	wake       chan struct{}
+	poll       time.Duration
+	jobTimeout time.Duration
+	staleAfter time.Duration
+}
+
+func NewJobQueue(repo *repositories.JobRepository) *JobQueue {
+	host, _ := os.Hostname()
+	return &JobQueue{
+		repo:       repo,
+		workerID:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		handlers:   map[string]JobHandler{},
+		wake:       make(chan struct{}, 1),
+		poll:       2 * time.Second,
+		jobTimeout: 5 * time.Minute,
+		staleAfter: 15 * time.Minute,
+	}
+}
+
+func (q *JobQueue) Register(kind string, handler JobHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+// Enqueue schedules a job. ownerID may be empty for system jobs.
+func (q *JobQueue) Enqueue(ctx context.Context, ownerID, kind string, payload any, opts EnqueueOptions) (models.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	job, err := q.repo.Enqueue(ctx, ownerID, kind, raw, opts.DedupeKey, opts.MaxAttempts, time.Now().Add(opts.Delay))
+	if err != nil {
+		return models.Job{}, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (q *JobQueue) Get(ctx context.Context, id, ownerID string) (models.Job, error) {
+	return q.repo.Get(ctx, id, ownerID)
+}
+
+func (q *JobQueue) List(ctx context.Context, ownerID, status, kind string, limit int) ([]models.Job, error) {
+	return q.repo.List(ctx, ownerID, status, kind, limit)
+}
+
+// Retry requeues a dead-lettered job.
+func (q *JobQueue) Retry(ctx context.Context, id, ownerID string) (models.Job, error) {
+	job, err := q.repo.Requeue(ctx, id, ownerID)
+	if err != nil {
+		return models.Job{}, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Start launches the worker goroutines and a janitor that purges old
+// succeeded jobs. Workers stop when ctx is cancelled. The worker count
+// comes from JOB_WORKERS when workers is zero.
+func (q *JobQueue) Start(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = 2
+		if v, err := strconv.Atoi(os.Getenv("JOB_WORKERS")); err == nil && v > 0 {
+			workers = v
+		}
+	}
+	for i := 0; i < workers; i++ {
+		go q.work(ctx, fmt.Sprintf("%s/%d", q.workerID, i))
+	}
+	go q.janitor(ctx)
+	slog.Info("jobs: workers started", "count", workers)
+}
+
+func (q *JobQueue) work(ctx context.Context, workerID string) {
+	for {
+		for q.runNext(ctx, workerID) {
+			if ctx.Err() != nil {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.poll):
+		}
+	}
+}
+
+// runNext claims and runs one job, reporting whether there was one.
+func (q *JobQueue) runNext(ctx context.Context, workerID string) bool {
+	job, err := q.repo.Claim(ctx, workerID, q.staleAfter)
+	if IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		slog.Error("jobs: claim failed", "error", err)
+		return false
+	}
+
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+	if !ok {
+		q.bury(ctx, job, workerID, "no handler registered for job kind "+job.Kind)
+		return true
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.jobTimeout)
+	result, err := runHandler(jobCtx, handler, job)
+	cancel()
+
+	if err == nil {
+		var raw []byte
+		if result != nil {
+			raw, _ = json.Marshal(result)
+		}
+		if err := q.repo.Complete(ctx, job.ID, workerID, raw); IsNotFound(err) {
+			slog.Warn("jobs: job reclaimed before it completed", "jobID", job.ID, "workerID", workerID)
+		} else if err != nil {
+			slog.Error("jobs: failed to mark job complete", "jobID", job.ID, "error", err)
+		}
+		return true
+	}
+
+	if errors.Is(err, ErrPermanent) || job.Attempts >= job.MaxAttempts {
+		q.bury(ctx, job, workerID, err.Error())
+		return true
+	}
+	delay := backoff(job.Attempts)
+	slog.Warn("jobs: job failed, retrying", "jobID", job.ID, "kind", job.Kind, "attempt", job.Attempts, "retryIn", delay, "error", err)
+	if err := q.repo.Retry(ctx, job.ID, workerID, err.Error(), time.Now().Add(delay)); errors.Is(err, repositories.ErrSuperseded) {
+		slog.Info("jobs: failed job superseded by a queued duplicate", "jobID", job.ID, "kind", job.Kind)
+	} else if IsNotFound(err) {
+		slog.Warn("jobs: job reclaimed before it was rescheduled", "jobID", job.ID, "workerID", workerID)
+	} else if err != nil {
+		slog.Error("jobs: failed to reschedule job", "jobID", job.ID, "error", err)
+	}
+	return true
+}
+
+func (q *JobQueue) bury(ctx context.Context, job models.Job, workerID, reason string) {
+	slog.Error("jobs: job dead-lettered", "jobID", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", reason)
+	if err := q.repo.Bury(ctx, job.ID, workerID, reason); IsNotFound(err) {
+		slog.Warn("jobs: job reclaimed before it was dead-lettered", "jobID", job.ID, "workerID", workerID)
+	} else if err != nil {
+		slog.Error("jobs: failed to dead-letter job", "jobID", job.ID, "error", err)
+	}
+}
+
+// runHandler converts handler panics into errors so one bad job can't take
+// down a worker.
+func runHandler(ctx context.Context, handler JobHandler, job models.Job) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff is exponential from 10s, capped at one hour, with up to 20% jitter.
+func backoff(attempt int) time.Duration {
+	d := 10 * time.Second
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+func (q *JobQueue) janitor(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := q.repo.PurgeFinished(ctx, time.Now().AddDate(0, 0, -7))
+			if err != nil {
+				slog.Error("jobs: purge failed", "error", err)
+			} else if n > 0 {
+				slog.Info("jobs: purged finished jobs", "count", n)
+			}
+		}
+	}
+}
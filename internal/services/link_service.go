@@ -2,28 +2,58 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/platform/embedding"
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/netguard"
+	"github.com/robstave/link-manager/internal/platform/readability"
 	"github.com/robstave/link-manager/internal/repositories"
+	"golang.org/x/net/publicsuffix"
 )
 
 type LinkService struct {
-	repo    *repositories.LinkRepository
-	metaSvc *MetadataService
+	repo     *repositories.LinkRepository
+	metaSvc  *MetadataService
+	embedder embedding.Embedder
+	jobs     *JobQueue
+
+	refreshAfter time.Duration
+	refreshBatch int
 }
 
-func NewLinkService(repo *repositories.LinkRepository, metaSvc *MetadataService) *LinkService {
-	return &LinkService{repo: repo, metaSvc: metaSvc}
+// NewLinkService configures the metadata refresh sweeper from
+// META_REFRESH_DAYS (default 30, 0 disables it) and META_REFRESH_BATCH
+// (links queued per hourly sweep, default 100).
+func NewLinkService(repo *repositories.LinkRepository, metaSvc *MetadataService, embedder embedding.Embedder, jobs *JobQueue) *LinkService {
+	return &LinkService{
+		repo:         repo,
+		metaSvc:      metaSvc,
+		embedder:     embedder,
+		jobs:         jobs,
+		refreshAfter: time.Duration(envInt("META_REFRESH_DAYS", 30)) * 24 * time.Hour,
+		refreshBatch: envInt("META_REFRESH_BATCH", 100),
+	}
 }
 
 func (s *LinkService) List(ctx context.Context, ownerID string, f repositories.LinkFilters) ([]repositories.LinkWithMeta, int, error) {
 	return s.repo.List(ctx, ownerID, f)
 }
 
+func (s *LinkService) Facets(ctx context.Context, ownerID string, f repositories.LinkFilters) (repositories.LinkFacets, error) {
+	return s.repo.Facets(ctx, ownerID, f)
+}
+
 func (s *LinkService) Create(ctx context.Context, ownerID string, req CreateLinkInput) (models.Link, error) {
 	projectID := req.ProjectID
 	categoryID := req.CategoryID
@@ -42,32 +72,275 @@ func (s *LinkService) Create(ctx context.Context, ownerID string, req CreateLink
 		categoryID = id
 	}
 	normURL := normalizeURL(req.URL)
-	title := req.Title
-	iconURL := req.IconURL
+	// Always enrich new links: even with a user-supplied title we want the
+	// structured metadata (content type, author, ...). Typed fields are kept.
+	status := MetadataStatusReady
+	if s.canEnrich(normURL) {
+		status = MetadataStatusPending
+	}
 
-	// Auto-fetch title and icon when title is empty
-	if title == "" && normURL != "" && s.metaSvc != nil {
-		slog.Info("link-create: title empty, auto-fetching metadata", "url", normURL)
-		meta, err := s.metaSvc.FetchPageMeta(normURL)
-		if err != nil {
-			slog.Error("link-create: auto-fetch failed", "url", normURL, "error", err)
-		} else {
-			slog.Info("link-create: auto-fetch succeeded", "url", normURL, "title", meta.Title, "description", meta.Description, "iconURL", meta.IconURL)
-			if meta.Title != "" {
-				title = meta.Title
-			}
-			if meta.Description != "" && req.Description == "" {
-				req.Description = meta.Description
-			}
-			if meta.IconURL != "" {
-				iconURL = meta.IconURL
-			}
+	edited := s.userEditedFields(ctx, normURL, req)
+	link, err := s.repo.Create(ctx, ownerID, projectID, categoryID, normURL, RegistrableDomain(normURL), req.Title, req.Description, req.UserNotes, req.IconURL, status, edited, req.Stars, req.Tags, req.CreatedAt)
+	if err != nil {
+		return models.Link{}, err
+	}
+	if status == MetadataStatusPending {
+		s.enqueueEnrichment(ctx, ownerID, link.ID, normURL)
+	}
+	s.refreshEmbedding(ctx, link.ID)
+	return link, nil
+}
+
+const (
+	MetadataStatusPending = "pending"
+	MetadataStatusReady   = "ready"
+	MetadataStatusFailed  = "failed"
+)
+
+// canEnrich reports whether page metadata for a link can be fetched in the
+// background.
+func (s *LinkService) canEnrich(normURL string) bool {
+	return normURL != "" && s.metaSvc != nil && s.jobs != nil
+}
+
+// userEditedFields lists the fields of a new link the user typed. A title or
+// description equal to the cached page metadata was prefilled from it (the
+// Add Link form does this), so it stays open to refreshes.
+func (s *LinkService) userEditedFields(ctx context.Context, normURL string, req CreateLinkInput) []string {
+	var cached PageMeta
+	if s.metaSvc != nil && (req.Title != "" || req.Description != "") {
+		cached, _ = s.metaSvc.CachedPageMeta(ctx, normURL)
+	}
+	var fields []string
+	if req.Title != "" && req.Title != cached.Title {
+		fields = append(fields, EditedTitle)
+	}
+	if req.Description != "" && req.Description != cached.Description {
+		fields = append(fields, EditedDescription)
+	}
+	if req.IconURL != "" {
+		fields = append(fields, EditedIconURL)
+	}
+	return fields
+}
+
+// Fields tracked in user_edited_fields.
+const (
+	EditedTitle       = "title"
+	EditedDescription = "description"
+	EditedIconURL     = "icon_url"
+)
+
+// ErrInvalidEditedField is returned for an unknown field in a refresh reset.
+var ErrInvalidEditedField = errors.New("reset fields must be title, description or icon_url")
+
+// updatedEditedFields carries the user-edited marks of an existing link
+// through an edit: a changed value is marked, a cleared title or description
+// is handed back to enrichment. An empty icon URL means unchanged.
+func updatedEditedFields(existing repositories.LinkWithMeta, req CreateLinkInput) []string {
+	marked := map[string]bool{}
+	for _, f := range existing.UserEditedFields {
+		marked[f] = true
+	}
+	for _, f := range []struct {
+		name       string
+		value, old string
+	}{
+		{EditedTitle, req.Title, existing.Title},
+		{EditedDescription, req.Description, existing.Description},
+	} {
+		if f.value == "" {
+			marked[f.name] = false
+		} else if f.value != f.old {
+			marked[f.name] = true
+		}
+	}
+	if req.IconURL != "" && req.IconURL != existing.IconURL {
+		marked[EditedIconURL] = true
+	}
+	fields := []string{}
+	for _, f := range []string{EditedTitle, EditedDescription, EditedIconURL} {
+		if marked[f] {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// EnrichMetadataPayload is the JobEnrichMetadata payload. Refresh bypasses
+// the metadata cache; Background marks a scheduled refresh, whose failure
+// leaves the link's status alone.
+type EnrichMetadataPayload struct {
+	LinkID     string `json:"link_id"`
+	URL        string `json:"url"`
+	Refresh    bool   `json:"refresh,omitempty"`
+	Background bool   `json:"background,omitempty"`
+}
+
+func (s *LinkService) enqueueEnrichment(ctx context.Context, ownerID, linkID, normURL string) {
+	_, err := s.jobs.Enqueue(ctx, ownerID, JobEnrichMetadata, EnrichMetadataPayload{LinkID: linkID, URL: normURL}, EnqueueOptions{DedupeKey: linkID + "|" + normURL, MaxAttempts: 3})
+	if err != nil {
+		slog.Error("link-enrich: failed to queue job", "linkID", linkID, "error", err)
+		if err := s.repo.SetMetadataStatus(ctx, linkID, MetadataStatusFailed); err != nil {
+			slog.Error("link-enrich: failed to mark link failed", "linkID", linkID, "error", err)
+		}
+	}
+}
+
+// Refresh queues a metadata refetch for a link, bypassing the cache, and
+// returns the job. reset names user-edited fields to hand back to enrichment.
+func (s *LinkService) Refresh(ctx context.Context, ownerID, linkID string, reset []string) (models.Job, error) {
+	for _, f := range reset {
+		if f != EditedTitle && f != EditedDescription && f != EditedIconURL {
+			return models.Job{}, ErrInvalidEditedField
+		}
+	}
+	link, err := s.repo.Get(ctx, linkID, ownerID)
+	if err != nil {
+		return models.Job{}, err
+	}
+	if !s.canEnrich(link.URL) {
+		return models.Job{}, ErrMetadataUnavailable
+	}
+	normURL, err := s.repo.RequestRefresh(ctx, linkID, ownerID, reset)
+	if err != nil {
+		return models.Job{}, err
+	}
+	job, err := s.jobs.Enqueue(ctx, ownerID, JobEnrichMetadata, EnrichMetadataPayload{LinkID: linkID, URL: normURL, Refresh: true}, EnqueueOptions{DedupeKey: linkID + "|" + normURL, MaxAttempts: 3})
+	if err != nil {
+		if err := s.repo.SetMetadataStatus(ctx, linkID, MetadataStatusFailed); err != nil {
+			slog.Error("link-enrich: failed to mark link failed", "linkID", linkID, "error", err)
 		}
-	} else if title != "" {
-		slog.Info("link-create: title provided, skipping auto-fetch", "title", title)
+		return models.Job{}, err
 	}
+	return job, nil
+}
+
+// ErrMetadataUnavailable is returned when metadata can't be fetched for a
+// link, e.g. because no job queue is configured.
+var ErrMetadataUnavailable = errors.New("metadata fetching is not available for this link")
 
-	return s.repo.Create(ctx, ownerID, projectID, categoryID, normURL, title, req.Description, req.UserNotes, iconURL, req.Stars, req.Tags)
+// RunMetadataRefresher queues background refreshes, hourly until ctx is
+// cancelled, for links whose metadata is older than META_REFRESH_DAYS. Each
+// sweep queues at most META_REFRESH_BATCH links, oldest first.
+func (s *LinkService) RunMetadataRefresher(ctx context.Context) {
+	if s.refreshAfter <= 0 || s.refreshBatch <= 0 || s.metaSvc == nil || s.jobs == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweepStaleMetadata(ctx)
+		}
+	}
+}
+
+func (s *LinkService) sweepStaleMetadata(ctx context.Context) {
+	stale, err := s.repo.StaleMetadata(ctx, time.Now().Add(-s.refreshAfter), s.refreshBatch)
+	if err != nil {
+		slog.Error("link-refresh: stale lookup failed", "error", err)
+		return
+	}
+	queued := 0
+	for _, l := range stale {
+		payload := EnrichMetadataPayload{LinkID: l.ID, URL: l.URL, Refresh: true, Background: true}
+		if _, err := s.jobs.Enqueue(ctx, l.OwnerID, JobEnrichMetadata, payload, EnqueueOptions{DedupeKey: l.ID + "|" + l.URL, MaxAttempts: 3}); err != nil {
+			slog.Error("link-refresh: failed to queue job", "linkID", l.ID, "error", err)
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		slog.Info("link-refresh: queued stale links", "count", queued)
+	}
+}
+
+// HandleEnrichMetadataJob is the JobEnrichMetadata handler. It fetches page
+// metadata and replaces whichever of title and description the user hasn't
+// edited, and the icon while it is still empty.
+func (s *LinkService) HandleEnrichMetadataJob(ctx context.Context, job models.Job) (any, error) {
+	var p EnrichMetadataPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %v: %w", err, ErrPermanent)
+	}
+	fetchMeta := s.metaSvc.FetchPageMeta
+	if p.Refresh || job.Attempts > 1 {
+		// A cached failure would otherwise fail every retry.
+		fetchMeta = s.metaSvc.RefreshPageMeta
+	}
+	meta, err := fetchMeta(ctx, p.URL)
+	if errors.Is(err, netguard.ErrBlocked) || errors.Is(err, fetch.ErrDisallowed) {
+		slog.Warn("link-enrich: destination blocked", "url", p.URL, "linkID", p.LinkID, "error", err)
+		s.markEnrichFailed(ctx, p)
+		return nil, fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	if err != nil {
+		slog.Warn("link-enrich: fetch failed", "url", p.URL, "linkID", p.LinkID, "attempt", job.Attempts, "error", err)
+		if job.Attempts >= job.MaxAttempts {
+			s.markEnrichFailed(ctx, p)
+		}
+		return nil, err
+	}
+	if err := s.repo.ApplyMetadata(ctx, p.LinkID, p.URL, repositories.LinkMetadata{
+		Title:           meta.Title,
+		Description:     meta.Description,
+		IconURL:         meta.IconURL,
+		SiteName:        meta.SiteName,
+		Author:          meta.Author,
+		PublishedAt:     meta.PublishedAt,
+		ContentType:     meta.ContentType,
+		DurationSeconds: meta.DurationSeconds,
+		ImageURL:        meta.ImageURL,
+		SiteData:        meta.SiteData,
+		ContentText:     meta.ContentText,
+		WordCount:       readability.CountWords(meta.ContentText),
+		MimeType:        meta.MimeType,
+		ContentLength:   meta.ContentLength,
+	}); err != nil {
+		if IsNotFound(err) {
+			// Deleted, or the URL was edited and a newer job owns enrichment.
+			return nil, nil
+		}
+		return nil, err
+	}
+	slog.Info("link-enrich: metadata applied", "url", p.URL, "linkID", p.LinkID, "title", meta.Title, "iconURL", meta.IconURL)
+	s.refreshEmbedding(ctx, p.LinkID)
+	// A refresh also re-downloads the icon, unless the user picked it.
+	if job.OwnerID != nil && !(p.Refresh && s.iconEdited(ctx, p.LinkID, *job.OwnerID)) {
+		if _, err := s.jobs.Enqueue(ctx, *job.OwnerID, JobFetchIcon, FetchIconPayload{LinkID: p.LinkID}, EnqueueOptions{DedupeKey: p.LinkID, MaxAttempts: 3}); err != nil {
+			slog.Error("link-enrich: failed to queue icon fetch", "linkID", p.LinkID, "error", err)
+		}
+	}
+	if job.OwnerID != nil && meta.ImageURL != "" {
+		if _, err := s.jobs.Enqueue(ctx, *job.OwnerID, JobFetchThumbnail, FetchThumbnailPayload{LinkID: p.LinkID}, EnqueueOptions{DedupeKey: p.LinkID, MaxAttempts: 3}); err != nil {
+			slog.Error("link-enrich: failed to queue thumbnail fetch", "linkID", p.LinkID, "error", err)
+		}
+	}
+	return map[string]string{"title": meta.Title, "description": meta.Description, "icon_url": meta.IconURL, "content_type": meta.ContentType}, nil
+}
+
+// markEnrichFailed records a final enrichment failure. Background refreshes
+// only note the attempt, so a page that is briefly down keeps its status,
+// unless a user refresh left it pending.
+func (s *LinkService) markEnrichFailed(ctx context.Context, p EnrichMetadataPayload) {
+	if p.Background {
+		if err := s.repo.TouchMetadataFetched(ctx, p.LinkID); err != nil {
+			slog.Error("link-refresh: failed to record attempt", "linkID", p.LinkID, "error", err)
+		}
+		return
+	}
+	if err := s.repo.SetMetadataStatus(ctx, p.LinkID, MetadataStatusFailed); err != nil {
+		slog.Error("link-enrich: failed to mark link failed", "linkID", p.LinkID, "error", err)
+	}
+}
+
+func (s *LinkService) iconEdited(ctx context.Context, linkID, ownerID string) bool {
+	link, err := s.repo.Get(ctx, linkID, ownerID)
+	return err == nil && slices.Contains(link.UserEditedFields, EditedIconURL)
 }
 
 func (s *LinkService) Get(ctx context.Context, linkID, ownerID string) (repositories.LinkWithMeta, error) {
@@ -95,32 +368,24 @@ func (s *LinkService) Update(ctx context.Context, ownerID, linkID string, req Cr
 		categoryID = id
 	}
 	normURL := normalizeURL(req.URL)
-	title := req.Title
-	iconURL := req.IconURL
-
-	// Auto-fetch title and icon when title is empty
-	if title == "" && normURL != "" && s.metaSvc != nil {
-		slog.Info("link-update: title empty, auto-fetching metadata", "url", normURL, "linkID", linkID)
-		meta, err := s.metaSvc.FetchPageMeta(normURL)
-		if err != nil {
-			slog.Error("link-update: auto-fetch failed", "url", normURL, "linkID", linkID, "error", err)
-		} else {
-			slog.Info("link-update: auto-fetch succeeded", "url", normURL, "linkID", linkID, "title", meta.Title, "description", meta.Description, "iconURL", meta.IconURL)
-			if meta.Title != "" {
-				title = meta.Title
-			}
-			if meta.Description != "" && req.Description == "" {
-				req.Description = meta.Description
-			}
-			if meta.IconURL != "" {
-				iconURL = meta.IconURL
-			}
-		}
-	} else if title != "" {
-		slog.Info("link-update: title provided, skipping auto-fetch", "title", title, "linkID", linkID)
+	existing, err := s.repo.Get(ctx, linkID, ownerID)
+	if err != nil {
+		return err
+	}
+	// Re-enrich when the title was cleared or the link now points elsewhere.
+	status := ""
+	if s.canEnrich(normURL) && (req.Title == "" || existing.URL != normURL) {
+		status = MetadataStatusPending
 	}
 
-	return s.repo.Update(ctx, ownerID, linkID, projectID, categoryID, normURL, title, req.Description, req.UserNotes, iconURL, req.Stars, req.Tags)
+	if err := s.repo.Update(ctx, ownerID, linkID, projectID, categoryID, normURL, RegistrableDomain(normURL), req.Title, req.Description, req.UserNotes, req.IconURL, status, updatedEditedFields(existing, req), req.Stars, req.Tags); err != nil {
+		return err
+	}
+	if status == MetadataStatusPending {
+		s.enqueueEnrichment(ctx, ownerID, linkID, normURL)
+	}
+	s.refreshEmbedding(ctx, linkID)
+	return nil
 }
 
 func (s *LinkService) UpdateStars(ctx context.Context, linkID, ownerID string, stars int) error {
@@ -132,8 +397,142 @@ func (s *LinkService) ToggleCart(ctx context.Context, linkID, ownerID string, ca
 func (s *LinkService) Delete(ctx context.Context, linkID, ownerID string) error {
 	return s.repo.Delete(ctx, linkID, ownerID)
 }
-func (s *LinkService) Export(ctx context.Context, ownerID string) ([]repositories.LinkWithMeta, error) {
-	return s.repo.Export(ctx, ownerID)
+func (s *LinkService) Export(ctx context.Context, ownerID string, f repositories.LinkFilters) ([]repositories.LinkWithMeta, error) {
+	return s.repo.Export(ctx, ownerID, f)
+}
+
+// SemanticSearch embeds the query and returns the nearest links by cosine
+// similarity, using only vectors produced by the current embedding model.
+func (s *LinkService) SemanticSearch(ctx context.Context, ownerID, query string, limit int) ([]repositories.LinkWithMeta, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SemanticSearch(ctx, ownerID, s.embedder.Model(), embedding.VectorLiteral(vectors[0]), limit)
+}
+
+// refreshEmbedding queues a job to recompute a link's embedding. Failures are
+// logged rather than returned so that an unavailable provider never blocks
+// saving a link.
+func (s *LinkService) refreshEmbedding(ctx context.Context, linkID string) {
+	if s.embedder == nil || s.jobs == nil {
+		return
+	}
+	if _, err := s.jobs.Enqueue(ctx, "", JobEmbedLink, EmbedLinkPayload{LinkID: linkID}, EnqueueOptions{DedupeKey: linkID}); err != nil {
+		slog.Error("embedding: failed to queue job", "linkID", linkID, "error", err)
+	}
+}
+
+type EmbedLinkPayload struct {
+	LinkID string `json:"link_id"`
+}
+
+// HandleEmbedLinkJob is the JobEmbedLink handler.
+func (s *LinkService) HandleEmbedLinkJob(ctx context.Context, job models.Job) (any, error) {
+	var p EmbedLinkPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %v: %w", err, ErrPermanent)
+	}
+	src, err := s.repo.EmbeddingSource(ctx, p.LinkID)
+	if IsNotFound(err) {
+		// Deleted since the job was queued; nothing to do.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{embeddingText(src)})
+	if err != nil {
+		return nil, fmt.Errorf("embedding provider %s: %w", s.embedder.Model(), err)
+	}
+	return nil, s.repo.SetEmbedding(ctx, p.LinkID, s.embedder.Model(), embedding.VectorLiteral(vectors[0]))
+}
+
+// BackfillEmbeddings embeds links that have no vector for the current model,
+// e.g. links created before semantic search existed or after switching providers.
+func (s *LinkService) BackfillEmbeddings(ctx context.Context) error {
+	const batchSize = 32
+	total := 0
+	for {
+		sources, err := s.repo.MissingEmbeddings(ctx, s.embedder.Model(), batchSize)
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			break
+		}
+		texts := make([]string, len(sources))
+		for i, src := range sources {
+			texts[i] = embeddingText(src)
+		}
+		vectors, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i, src := range sources {
+			if err := s.repo.SetEmbedding(ctx, src.ID, s.embedder.Model(), embedding.VectorLiteral(vectors[i])); err != nil {
+				return err
+			}
+		}
+		total += len(sources)
+	}
+	if total > 0 {
+		slog.Info("embedding: backfill complete", "model", s.embedder.Model(), "count", total)
+	}
+	return nil
+}
+
+// SimilarLink is a neighbouring link with a human-readable explanation of
+// why it was suggested.
+type SimilarLink struct {
+	repositories.LinkWithMeta
+	Score   float64
+	Reasons []string
+}
+
+func (s *LinkService) Similar(ctx context.Context, linkID, ownerID string, limit int) ([]SimilarLink, error) {
+	if _, err := s.repo.Get(ctx, linkID, ownerID); err != nil {
+		return nil, err
+	}
+	candidates, err := s.repo.Similar(ctx, linkID, ownerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SimilarLink, 0, len(candidates))
+	for _, c := range candidates {
+		results = append(results, SimilarLink{LinkWithMeta: c.LinkWithMeta, Score: c.Score, Reasons: similarReasons(c)})
+	}
+	return results, nil
+}
+
+func similarReasons(c repositories.SimilarLink) []string {
+	reasons := []string{}
+	if c.VectorScore >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("semantically similar (%.0f%%)", c.VectorScore*100))
+	}
+	if len(c.SharedTags) > 0 {
+		reasons = append(reasons, "shares tags: "+strings.Join(c.SharedTags, ", "))
+	}
+	if c.SameDomain {
+		reasons = append(reasons, "same site: "+c.Domain)
+	}
+	if c.TextScore >= 0.1 {
+		reasons = append(reasons, "overlapping keywords in title or description")
+	}
+	return reasons
+}
+
+func embeddingText(src repositories.EmbeddingSource) string {
+	parts := []string{}
+	for _, p := range []string{src.Title, src.Description, src.UserNotes, src.GeneratedNotes} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return src.URL
+	}
+	return strings.Join(parts, "\n\n")
 }
 
 func IsNotFound(err error) bool {
@@ -146,6 +545,56 @@ type CreateLinkInput struct {
 	ProjectID, CategoryID              string
 	Tags                               []string
 	Stars                              int
+	// CreatedAt backdates an imported link; nil means now.
+	CreatedAt *time.Time
+}
+
+// RegistrableDomain returns the eTLD+1 of a URL's host ("docs.github.com" ->
+// "github.com", "bbc.co.uk" stays "bbc.co.uk"). IP addresses and hosts without
+// a public suffix, such as "localhost", are returned as-is.
+func RegistrableDomain(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// BackfillDomains fills in the domain of links saved before it was stored.
+func (s *LinkService) BackfillDomains(ctx context.Context) error {
+	const batchSize = 500
+	total, afterID := 0, ""
+	for {
+		links, err := s.repo.MissingDomains(ctx, afterID, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, l := range links {
+			// Unparseable URLs stay empty; paging by id moves past them.
+			if domain := RegistrableDomain(l.URL); domain != "" {
+				if err := s.repo.SetDomain(ctx, l.ID, domain); err != nil {
+					return err
+				}
+				total++
+			}
+			afterID = l.ID
+		}
+		if len(links) < batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("domains: backfill complete", "count", total)
+	}
+	return nil
 }
 
 func normalizeURL(raw string) string {
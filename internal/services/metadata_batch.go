@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+var ErrBatchTooLarge = errors.New("too many urls in batch")
+
+// batchLimits bound a batch preview: how many URLs it may hold, how many are
+// fetched at once, and how many of those may share a host. The fetch
+// client's per-host rate limit still applies underneath; the per-host cap
+// keeps a batch of one site's pages from tying up every worker while they
+// wait their turn.
+type batchLimits struct {
+	maxURLs int
+	workers int
+	perHost int
+}
+
+// BatchPreviewResult is one URL of a batch: its preview, or why there isn't
+// one. Index is the URL's position in the request.
+type BatchPreviewResult struct {
+	Index   int
+	URL     string
+	Preview LinkPreview
+	Err     error
+}
+
+// PreviewBatch previews urls concurrently, META_BATCH_WORKERS (default 8) at
+// a time and at most META_BATCH_PER_HOST (default 2) per host, and calls emit
+// with each result as it finishes, so in completion order. emit is never
+// called concurrently. A failed URL is reported through its result; the
+// batch itself only fails when it holds more than META_BATCH_MAX_URLS
+// (default 50), before anything is emitted.
+func (s *MetadataService) PreviewBatch(ctx context.Context, urls []string, emit func(BatchPreviewResult)) error {
+	if len(urls) > s.batch.maxURLs {
+		return fmt.Errorf("%w: %d, at most %d", ErrBatchTooLarge, len(urls), s.batch.maxURLs)
+	}
+
+	workers := make(chan struct{}, s.batch.workers)
+	hosts := map[string]chan struct{}{}
+	results := make(chan BatchPreviewResult)
+	var wg sync.WaitGroup
+	for i, rawURL := range urls {
+		host := batchHost(rawURL)
+		if hosts[host] == nil {
+			hosts[host] = make(chan struct{}, s.batch.perHost)
+		}
+		hostSlots := hosts[host]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := BatchPreviewResult{Index: i, URL: rawURL}
+			res.Preview, res.Err = s.previewSlot(ctx, rawURL, hostSlots, workers)
+			results <- res
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for res := range results {
+		emit(res)
+	}
+	return nil
+}
+
+// previewSlot runs Preview once a slot for the URL's host and then a worker
+// are free. The host slot is taken first so waiting on a busy host doesn't
+// hold a worker. A panic while previewing fails only this URL: nothing up
+// the goroutine's stack would recover it, and it would take down the server.
+func (s *MetadataService) previewSlot(ctx context.Context, rawURL string, hostSlots, workers chan struct{}) (preview LinkPreview, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("meta: batch preview panicked", "url", rawURL, "panic", r, "stack", string(debug.Stack()))
+			preview, err = LinkPreview{}, fmt.Errorf("preview panicked: %v", r)
+		}
+	}()
+	if err := acquire(ctx, hostSlots); err != nil {
+		return LinkPreview{}, err
+	}
+	defer func() { <-hostSlots }()
+	if err := acquire(ctx, workers); err != nil {
+		return LinkPreview{}, err
+	}
+	defer func() { <-workers }()
+	return s.Preview(ctx, rawURL)
+}
+
+func acquire(ctx context.Context, slots chan struct{}) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// batchHost groups URLs for the per-host cap. Unparseable URLs share the
+// empty host; they fail fast anyway.
+func batchHost(rawURL string) string {
+	u, err := url.Parse(normalizeURL(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/netguard"
+)
+
+type panickyExtractor struct{}
+
+func (panickyExtractor) Name() string { return "panicky" }
+
+func (panickyExtractor) Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta) {
+	if page.Path == "/boom" {
+		panic("extractor bug")
+	}
+}
+
+// A panic while previewing one URL fails that URL, not the process.
+func TestPreviewBatchRecoversPanics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><head><title>Page "+r.URL.Path+"</title></head><body></body></html>")
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	guard := netguard.NewGuard(netguard.Options{Deny: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Ports: []int{port}})
+	svc := NewMetadataService(fetch.NewClient(guard, fetch.Options{IgnoreRobots: true, RatePerHost: 100}), nil)
+	svc.RegisterExtractor(u.Hostname(), panickyExtractor{})
+
+	urls := []string{srv.URL + "/ok", srv.URL + "/boom", srv.URL + "/fine"}
+	results := map[int]BatchPreviewResult{}
+	err := svc.PreviewBatch(context.Background(), urls, func(res BatchPreviewResult) {
+		results[res.Index] = res
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(results), len(urls))
+	}
+	if results[1].Err == nil {
+		t.Error("panicking URL reported no error")
+	}
+	for _, i := range []int{0, 2} {
+		if res := results[i]; res.Err != nil || res.Preview.Title == "" {
+			t.Errorf("%s: title %q, err %v", res.URL, res.Preview.Title, res.Err)
+		}
+	}
+}
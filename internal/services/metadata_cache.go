@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+// FetchPageMeta returns metadata for a URL, served from the URL cache while
+// fresh. Expired entries are revalidated with a conditional GET, and recent
+// failures are answered from the cache without contacting the site.
+func (s *MetadataService) FetchPageMeta(ctx context.Context, rawURL string) (PageMeta, error) {
+	return s.fetchCached(ctx, rawURL, false)
+}
+
+// RefreshPageMeta is FetchPageMeta ignoring cache freshness, including cached
+// failures. It still revalidates conditionally, so an unchanged page costs a
+// 304.
+func (s *MetadataService) RefreshPageMeta(ctx context.Context, rawURL string) (PageMeta, error) {
+	return s.fetchCached(ctx, rawURL, true)
+}
+
+// CachedPageMeta returns the cached metadata for a URL, fresh or not, without
+// fetching. ok is false when nothing usable is cached.
+func (s *MetadataService) CachedPageMeta(ctx context.Context, rawURL string) (meta PageMeta, ok bool) {
+	if s.cache == nil {
+		return PageMeta{}, false
+	}
+	entry, err := s.cache.Get(ctx, canonicalURL(rawURL))
+	if err != nil || entry.Error != "" {
+		return PageMeta{}, false
+	}
+	if err := json.Unmarshal(entry.Meta, &meta); err != nil {
+		return PageMeta{}, false
+	}
+	return meta, true
+}
+
+func (s *MetadataService) fetchCached(ctx context.Context, rawURL string, force bool) (PageMeta, error) {
+	if err := s.guard.CheckRawURL(rawURL); err != nil {
+		return PageMeta{}, err
+	}
+	if s.cache == nil {
+		meta, _, err := s.scrapePageMeta(ctx, rawURL, "", "")
+		return meta, err
+	}
+	key := canonicalURL(rawURL)
+
+	entry, err := s.cache.Get(ctx, key)
+	cached := err == nil
+	if err != nil && !IsNotFound(err) {
+		slog.Error("meta-cache: lookup failed", "url", key, "error", err)
+	}
+	var hit PageMeta
+	// Entries written before the final URL was recorded are refetched in
+	// full; revalidating them would keep the gap.
+	usable := cached && entry.Error == "" && json.Unmarshal(entry.Meta, &hit) == nil && hit.FinalURL != ""
+	if cached && !force && time.Now().Before(entry.ExpiresAt) {
+		if entry.Error != "" {
+			slog.Info("meta-cache: negative hit", "url", key, "error", entry.Error)
+			var page PageMeta
+			if json.Unmarshal(entry.Meta, &page) == nil && page.StatusCode != 0 {
+				return PageMeta{}, fmt.Errorf("failed to fetch URL (cached): %w", &statusError{page: page})
+			}
+			return PageMeta{}, fmt.Errorf("failed to fetch URL (cached): %s", entry.Error)
+		}
+		if usable {
+			slog.Info("meta-cache: hit", "url", key)
+			return hit, nil
+		}
+	}
+
+	etag, lastModified := "", ""
+	if usable {
+		etag, lastModified = entry.ETag, entry.LastModified
+	}
+	meta, result, err := s.scrapePageMeta(ctx, rawURL, etag, lastModified)
+	if err != nil {
+		if ctx.Err() == nil {
+			failed := repositories.MetadataCacheEntry{URL: key, Error: err.Error(), ExpiresAt: time.Now().Add(s.negativeTTL)}
+			// Keep an error status, so a cached failure still previews with it.
+			var se *statusError
+			if errors.As(err, &se) {
+				failed.Meta, _ = json.Marshal(se.page)
+			}
+			s.store(ctx, failed)
+		}
+		return PageMeta{}, err
+	}
+	if result.notModified {
+		meta = hit
+		if err := s.cache.Touch(ctx, key, time.Now().Add(s.ttl)); err != nil {
+			slog.Error("meta-cache: touch failed", "url", key, "error", err)
+		}
+		return meta, nil
+	}
+
+	raw, err := json.Marshal(meta)
+	if err == nil {
+		s.store(ctx, repositories.MetadataCacheEntry{URL: key, Meta: raw, ETag: result.etag, LastModified: result.lastModified, ExpiresAt: time.Now().Add(s.ttl)})
+	}
+	return meta, nil
+}
+
+func (s *MetadataService) store(ctx context.Context, entry repositories.MetadataCacheEntry) {
+	if err := s.cache.Put(ctx, entry); err != nil {
+		slog.Error("meta-cache: store failed", "url", entry.URL, "error", err)
+	}
+}
+
+// RunCacheJanitor deletes cache entries that expired over a week ago, hourly,
+// until ctx is cancelled. Recently expired entries are kept for their
+// validators.
+func (s *MetadataService) RunCacheJanitor(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.cache.PurgeExpired(ctx, time.Now().AddDate(0, 0, -7))
+			if err != nil {
+				slog.Error("meta-cache: purge failed", "error", err)
+			} else if n > 0 {
+				slog.Info("meta-cache: purged expired entries", "count", n)
+			}
+		}
+	}
+}
+
+// trackingParams are query parameters that never change what a page shows.
+var trackingParams = map[string]bool{"fbclid": true, "gclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true, "ref_src": true}
+
+// canonicalURL is the cache key for a URL: lower-case scheme and host,
+// default port and fragment dropped, tracking parameters removed and the
+// remaining query sorted.
+func canonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(strings.ToLower(k), "utm_") || trackingParams[strings.ToLower(k)] {
+			q.Del(k)
+		}
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	u.RawQuery = strings.Join(parts, "&")
+	return u.String()
+}
+
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+		slog.Warn("invalid integer, using default", "key", key, "value", v, "default", def)
+	}
+	return def
+}
+
+func envBytes(key string, def int64) int64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+		slog.Warn("invalid byte size, using default", "key", key, "value", v, "default", def)
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("invalid duration, using default", "key", key, "value", v, "default", def)
+	}
+	return def
+}
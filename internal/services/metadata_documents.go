@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/robstave/link-manager/internal/platform/imaging"
+	"github.com/robstave/link-manager/internal/platform/pdf"
+	"github.com/robstave/link-manager/internal/platform/readability"
+)
+
+// descriptionLength caps descriptions built from document text.
+const descriptionLength = 300
+
+// responseMIME returns the media type of a response, sniffing the body when
+// the server sent none or a generic one. PDFs served as octet-stream are
+// common.
+func responseMIME(header http.Header, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		if pdf.IsPDF(body) {
+			return "application/pdf"
+		}
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	return strings.ToLower(mediaType)
+}
+
+func isHTMLMIME(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// responseLength prefers Content-Length, since the body may have been cut at
+// the size cap.
+func responseLength(header http.Header, body []byte) int64 {
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+		return n
+	}
+	return int64(len(body))
+}
+
+// describeDocument fills meta for a non-HTML response: PDFs, images and
+// text files. Anything else only gets its file name as title.
+func describeDocument(meta *PageMeta, rawURL, mediaType string, body []byte) {
+	switch {
+	case mediaType == "application/pdf":
+		describePDF(meta, body)
+	case strings.HasPrefix(mediaType, "image/"):
+		describeImage(meta, rawURL, body)
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/json", mediaType == "application/x-yaml":
+		describeText(meta, mediaType, body)
+	}
+	if meta.Title == "" {
+		meta.Title = fileName(rawURL)
+	}
+}
+
+func describePDF(meta *PageMeta, body []byte) {
+	meta.ContentType = ContentTypePaper
+	info, err := pdf.Parse(body)
+	if err != nil {
+		slog.Warn("meta: pdf parse failed", "error", err)
+		return
+	}
+	meta.Title = cleanText(info.Title)
+	meta.Author = cleanText(info.Author)
+	meta.PublishedAt = info.Created
+	meta.Description = cleanText(info.Subject)
+	if meta.Description == "" {
+		meta.Description = excerpt(info.FirstPageText, descriptionLength)
+	}
+	meta.ContentText = info.FirstPageText
+	if info.Pages > 0 {
+		setSiteData(meta, "pages", strconv.Itoa(info.Pages))
+	}
+}
+
+func describeImage(meta *PageMeta, rawURL string, body []byte) {
+	meta.ContentType = ContentTypeImage
+	// The image is its own preview.
+	meta.ImageURL = rawURL
+	w, h, format, err := imaging.Dimensions(body)
+	if err != nil {
+		return
+	}
+	setSiteData(meta, "width", strconv.Itoa(w))
+	setSiteData(meta, "height", strconv.Itoa(h))
+	setSiteData(meta, "format", format)
+}
+
+// describeText takes the first line as the title, stripped of Markdown
+// heading marks, and the lines after it as the description.
+func describeText(meta *PageMeta, mediaType string, body []byte) {
+	meta.ContentType = ContentTypeDocument
+	text := strings.ToValidUTF8(string(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))), "")
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) >= 12 {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	first := lines[0]
+	if mediaType != "text/csv" {
+		first = strings.TrimSpace(strings.TrimLeft(first, "#"))
+		meta.Title = excerpt(first, 200)
+		lines = lines[1:]
+	}
+	meta.Description = excerpt(strings.Join(lines, " "), descriptionLength)
+	if len(text) > readability.MaxTextLength {
+		cut := readability.MaxTextLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	meta.ContentText = strings.TrimSpace(text)
+}
+
+// excerpt collapses whitespace and cuts s to at most n bytes at a word
+// boundary, marking the cut with an ellipsis.
+func excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	if i := strings.LastIndex(s[:cut], " "); i > n/2 {
+		cut = i
+	}
+	return s[:cut] + "…"
+}
+
+// fileName is the last path segment of a URL, unescaped.
+func fileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	base := path.Base(u.Path)
+	if base == "/" || base == "." {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(base); err == nil {
+		base = unescaped
+	}
+	return base
+}
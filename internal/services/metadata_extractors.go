@@ -0,0 +1,98 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Extractor pulls site-specific metadata out of a fetched page. It runs after
+// the generic extraction (OpenGraph, JSON-LD, oEmbed) with meta already
+// filled in, and overwrites only what it knows better.
+type Extractor interface {
+	Name() string
+	Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta)
+}
+
+// ExtractorRegistry maps host patterns to extractors. A pattern matches its
+// host and every subdomain of it: "youtube.com" covers www.youtube.com and
+// m.youtube.com.
+type ExtractorRegistry struct {
+	mu      sync.RWMutex
+	entries []extractorEntry
+}
+
+type extractorEntry struct {
+	pattern   string
+	extractor Extractor
+}
+
+// NewExtractorRegistry returns a registry holding the built-in extractors.
+func NewExtractorRegistry() *ExtractorRegistry {
+	r := &ExtractorRegistry{}
+	r.Register("youtube.com", youTubeExtractor{})
+	r.Register("youtu.be", youTubeExtractor{})
+	r.Register("github.com", gitHubExtractor{})
+	r.Register("arxiv.org", arXivExtractor{})
+	r.Register("reddit.com", redditExtractor{})
+	r.Register("news.ycombinator.com", hackerNewsExtractor{})
+	return r
+}
+
+// Register adds an extractor for a host pattern. Later registrations take
+// precedence, so a pattern can be overridden.
+func (r *ExtractorRegistry) Register(pattern string, e Extractor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pattern = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(pattern)), "*.")
+	r.entries = append([]extractorEntry{{pattern: pattern, extractor: e}}, r.entries...)
+}
+
+// For returns the extractor for a URL, or nil when only the generic
+// extraction applies. The longest matching pattern wins.
+func (r *ExtractorRegistry) For(u *url.URL) Extractor {
+	if r == nil || u == nil {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var best *extractorEntry
+	for i, entry := range r.entries {
+		if host != entry.pattern && !strings.HasSuffix(host, "."+entry.pattern) {
+			continue
+		}
+		if best == nil || len(entry.pattern) > len(best.pattern) {
+			best = &r.entries[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.extractor
+}
+
+// RegisterExtractor adds a site-specific extractor to the service.
+func (s *MetadataService) RegisterExtractor(pattern string, e Extractor) {
+	s.extractors.Register(pattern, e)
+}
+
+// setSiteData records a site-specific attribute, skipping empty values.
+func setSiteData(meta *PageMeta, key, value string) {
+	if value = strings.TrimSpace(value); value == "" {
+		return
+	}
+	if meta.SiteData == nil {
+		meta.SiteData = map[string]string{}
+	}
+	meta.SiteData[key] = value
+}
+
+// metaAttr returns the trimmed content attribute of the first element
+// matching selector.
+func metaAttr(doc *goquery.Selection, selector string) string {
+	val, _ := doc.Find(selector).First().Attr("content")
+	return strings.TrimSpace(val)
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var digitsRe = regexp.MustCompile(`\d[\d,]*`)
+
+// leadingCount returns the first number in s without separators, e.g. "1,204"
+// for "1,204 comments".
+func leadingCount(s string) string {
+	return strings.ReplaceAll(digitsRe.FindString(s), ",", "")
+}
+
+// ── YouTube ──────────────────────────────────────────────────────────────────
+
+type youTubeExtractor struct{}
+
+func (youTubeExtractor) Name() string { return "youtube" }
+
+func (youTubeExtractor) Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta) {
+	meta.SiteName = "YouTube"
+	// twitter:title carries no channel prefix or " - YouTube" suffix.
+	if title := metaAttr(doc, `meta[name="twitter:title"]`); title != "" {
+		meta.Title = cleanText(title)
+	} else {
+		meta.Title = strings.TrimSuffix(meta.Title, " - YouTube")
+	}
+	author := doc.Find(`[itemprop="author"] [itemprop="name"]`).First()
+	if name, _ := author.Attr("content"); strings.TrimSpace(name) != "" {
+		meta.Author = cleanText(name)
+	}
+	if href, ok := doc.Find(`[itemprop="author"] [itemprop="url"]`).First().Attr("href"); ok {
+		setSiteData(meta, "channel_url", resolveURL(href, page.String()))
+	}
+	if meta.PublishedAt == nil {
+		meta.PublishedAt = parseMetaTime(metaAttr(doc, `meta[itemprop="uploadDate"]`))
+	}
+	setSiteData(meta, "views", metaAttr(doc, `meta[itemprop="interactionCount"]`))
+
+	if id := youTubeVideoID(page); id != "" {
+		setSiteData(meta, "video_id", id)
+		meta.ContentType = ContentTypeVideo
+	}
+}
+
+func youTubeVideoID(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	switch {
+	case host == "youtu.be" && len(segments) > 0:
+		return segments[0]
+	case u.Path == "/watch":
+		return u.Query().Get("v")
+	case len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "live" || segments[0] == "embed"):
+		return segments[1]
+	}
+	return ""
+}
+
+// ── GitHub ───────────────────────────────────────────────────────────────────
+
+type gitHubExtractor struct{}
+
+func (gitHubExtractor) Name() string { return "github" }
+
+// gitHubReserved are first path segments that are GitHub pages, not owners.
+var gitHubReserved = map[string]bool{
+	"about": true, "collections": true, "explore": true, "features": true, "login": true, "marketplace": true,
+	"notifications": true, "orgs": true, "pricing": true, "search": true, "settings": true, "sponsors": true,
+	"topics": true, "trending": true,
+}
+
+func (gitHubExtractor) Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta) {
+	meta.SiteName = "GitHub"
+	segments := strings.FieldsFunc(page.Path, func(r rune) bool { return r == '/' })
+	if len(segments) < 2 || gitHubReserved[strings.ToLower(segments[0])] {
+		return
+	}
+	owner, repo := segments[0], segments[1]
+	setSiteData(meta, "repo", owner+"/"+repo)
+	meta.Author = owner
+	if len(segments) > 2 {
+		// Issues, pull requests and files keep their own titles.
+		return
+	}
+	meta.Title = owner + "/" + repo
+	meta.ContentType = ContentTypeRepo
+
+	// The About box holds the repo description as typed; the meta tags append
+	// "Contribute to ... on GitHub" boilerplate.
+	if about := cleanText(doc.Find(`.BorderGrid-cell p.f4`).First().Text()); about != "" {
+		meta.Description = about
+	} else if strings.HasPrefix(meta.Description, "Contribute to ") {
+		meta.Description = ""
+	}
+
+	stars := doc.Find(`#repo-stars-counter-star`).First()
+	if v, ok := stars.Attr("title"); ok {
+		setSiteData(meta, "stars", leadingCount(v))
+	} else {
+		setSiteData(meta, "stars", leadingCount(doc.Find(`a[href$="/stargazers"] strong`).First().Text()))
+	}
+	if v, ok := doc.Find(`#repo-network-counter`).First().Attr("title"); ok {
+		setSiteData(meta, "forks", leadingCount(v))
+	}
+
+	language := cleanText(doc.Find(`[itemprop="programmingLanguage"]`).First().Text())
+	if language == "" {
+		doc.Find(`h2`).EachWithBreak(func(_ int, h *goquery.Selection) bool {
+			if strings.TrimSpace(h.Text()) != "Languages" {
+				return true
+			}
+			language = cleanText(h.Parent().Find(`li span.text-bold`).First().Text())
+			return false
+		})
+	}
+	setSiteData(meta, "language", language)
+
+	var topics []string
+	doc.Find(`a.topic-tag`).Each(func(_ int, a *goquery.Selection) {
+		if t := strings.TrimSpace(a.Text()); t != "" {
+			topics = append(topics, t)
+		}
+	})
+	setSiteData(meta, "topics", strings.Join(topics, ", "))
+}
+
+// ── arXiv ────────────────────────────────────────────────────────────────────
+
+type arXivExtractor struct{}
+
+func (arXivExtractor) Name() string { return "arxiv" }
+
+func (arXivExtractor) Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta) {
+	meta.SiteName = "arXiv"
+	title := metaAttr(doc, `meta[name="citation_title"]`)
+	if title == "" {
+		// Abstract pages without citation tags: <h1 class="title"><span class="descriptor">Title:</span>...
+		h1 := doc.Find(`h1.title`).First().Clone()
+		h1.Find(`.descriptor`).Remove()
+		title = h1.Text()
+	}
+	if title = cleanText(title); title == "" {
+		return
+	}
+	meta.Title = title
+	meta.ContentType = ContentTypePaper
+
+	var authors []string
+	doc.Find(`meta[name="citation_author"]`).Each(func(_ int, m *goquery.Selection) {
+		name, _ := m.Attr("content")
+		// citation_author is "Last, First".
+		if last, first, ok := strings.Cut(name, ","); ok {
+			name = strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+		}
+		if name = cleanText(name); name != "" {
+			authors = append(authors, name)
+		}
+	})
+	if len(authors) == 0 {
+		doc.Find(`div.authors a`).Each(func(_ int, a *goquery.Selection) {
+			if name := cleanText(a.Text()); name != "" {
+				authors = append(authors, name)
+			}
+		})
+	}
+	if len(authors) > 0 {
+		meta.Author = strings.Join(authors, ", ")
+	}
+
+	abstract := metaAttr(doc, `meta[name="citation_abstract"]`)
+	if abstract == "" {
+		block := doc.Find(`blockquote.abstract`).First().Clone()
+		block.Find(`.descriptor`).Remove()
+		abstract = block.Text()
+	}
+	if abstract = cleanText(abstract); abstract != "" {
+		meta.Description = abstract
+	}
+
+	if t := parseMetaTime(firstNonEmpty(metaAttr(doc, `meta[name="citation_date"]`), metaAttr(doc, `meta[name="citation_online_date"]`))); t != nil {
+		meta.PublishedAt = t
+	}
+	id := metaAttr(doc, `meta[name="citation_arxiv_id"]`)
+	if id == "" {
+		if rest, ok := strings.CutPrefix(page.Path, "/abs/"); ok {
+			id = rest
+		}
+	}
+	setSiteData(meta, "arxiv_id", id)
+	setSiteData(meta, "primary_category", cleanText(doc.Find(`.primary-subject`).First().Text()))
+	setSiteData(meta, "pdf_url", metaAttr(doc, `meta[name="citation_pdf_url"]`))
+}
+
+// ── Reddit ───────────────────────────────────────────────────────────────────
+
+type redditExtractor struct{}
+
+func (redditExtractor) Name() string { return "reddit" }
+
+func (redditExtractor) Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta) {
+	meta.SiteName = "Reddit"
+	// New Reddit renders posts as <shreddit-post> with everything in attributes.
+	if post := doc.Find(`shreddit-post`).First(); post.Length() > 0 {
+		attr := func(name string) string { v, _ := post.Attr(name); return strings.TrimSpace(v) }
+		if t := attr("post-title"); t != "" {
+			meta.Title = cleanText(t)
+		}
+		if a := attr("author"); a != "" {
+			meta.Author = a
+		}
+		if t := parseMetaTime(attr("created-timestamp")); t != nil {
+			meta.PublishedAt = t
+		}
+		setSiteData(meta, "subreddit", attr("subreddit-prefixed-name"))
+		setSiteData(meta, "score", attr("score"))
+		setSiteData(meta, "comments", attr("comment-count"))
+		return
+	}
+	// Old Reddit keeps the same details in data- attributes on the post.
+	if thing := doc.Find(`#siteTable .thing.link`).First(); thing.Length() > 0 {
+		attr := func(name string) string { v, _ := thing.Attr(name); return strings.TrimSpace(v) }
+		if t := cleanText(thing.Find(`a.title`).First().Text()); t != "" {
+			meta.Title = t
+		}
+		if a := attr("data-author"); a != "" {
+			meta.Author = a
+		}
+		if ms, err := strconv.ParseInt(attr("data-timestamp"), 10, 64); err == nil {
+			t := time.UnixMilli(ms).UTC()
+			meta.PublishedAt = &t
+		}
+		setSiteData(meta, "subreddit", attr("data-subreddit-prefixed"))
+		setSiteData(meta, "score", attr("data-score"))
+		setSiteData(meta, "comments", attr("data-comments-count"))
+	}
+}
+
+// ── Hacker News ──────────────────────────────────────────────────────────────
+
+type hackerNewsExtractor struct{}
+
+func (hackerNewsExtractor) Name() string { return "hackernews" }
+
+func (hackerNewsExtractor) Extract(page *url.URL, doc *goquery.Selection, meta *PageMeta) {
+	meta.SiteName = "Hacker News"
+	meta.Title = strings.TrimSuffix(meta.Title, " | Hacker News")
+	item := doc.Find(`.fatitem`).First()
+	if item.Length() == 0 {
+		return
+	}
+	story := item.Find(`.titleline > a`).First()
+	if t := cleanText(story.Text()); t != "" {
+		meta.Title = t
+	}
+	if href, ok := story.Attr("href"); ok && !strings.HasPrefix(href, "item?") {
+		setSiteData(meta, "story_url", resolveURL(href, page.String()))
+	}
+	if a := strings.TrimSpace(item.Find(`.hnuser`).First().Text()); a != "" {
+		meta.Author = a
+	}
+	// The age link's title is "2006-01-02T15:04:05 <unix seconds>".
+	if v, ok := item.Find(`.age`).First().Attr("title"); ok {
+		stamp, _, _ := strings.Cut(v, " ")
+		if t := parseMetaTime(stamp); t != nil {
+			meta.PublishedAt = t
+		}
+	}
+	if text := cleanText(item.Find(`.toptext`).First().Text()); text != "" && meta.Description == "" {
+		meta.Description = text
+	}
+	setSiteData(meta, "points", leadingCount(item.Find(`.score`).First().Text()))
+	item.Find(`.subline a`).Each(func(_ int, a *goquery.Selection) {
+		if strings.Contains(a.Text(), "comment") {
+			setSiteData(meta, "comments", leadingCount(a.Text()))
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// TestSiteExtractors runs each site extractor over saved pages and compares
+// the result with testdata/extractors/<name>.golden.json. Run with -update
+// to regenerate the golden files after an intended change.
+func TestSiteExtractors(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		extractor string
+	}{
+		{"youtube_watch", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "youtube"},
+		{"github_repo", "https://github.com/gocolly/colly", "github"},
+		{"github_issue", "https://github.com/gocolly/colly/issues/812", "github"},
+		{"arxiv_abs", "https://arxiv.org/abs/1706.03762", "arxiv"},
+		{"arxiv_abs_no_citation", "https://arxiv.org/abs/2101.00001", "arxiv"},
+		{"reddit_new", "https://www.reddit.com/r/golang/comments/1abcde2/what_is_your_favourite_go_library/", "reddit"},
+		{"reddit_old", "https://old.reddit.com/r/golang/comments/1abcde2/what_is_your_favourite_go_library/", "reddit"},
+		{"hackernews_item", "https://news.ycombinator.com/item?id=38000000", "hackernews"},
+		{"hackernews_ask", "https://news.ycombinator.com/item?id=38000001", "hackernews"},
+	}
+	registry := NewExtractorRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := os.ReadFile(filepath.Join("testdata", "extractors", tt.name+".html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, _ := url.Parse(tt.url)
+			ex := registry.For(page)
+			if ex == nil || ex.Name() != tt.extractor {
+				t.Fatalf("extractor for %s = %v, want %s", tt.url, ex, tt.extractor)
+			}
+
+			meta := genericMeta(doc.Selection)
+			ex.Extract(page, doc.Selection, &meta)
+			got, err := json.MarshalIndent(meta, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "extractors", tt.name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s mismatch\n--- got\n%s\n--- want\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+// genericMeta stands in for the generic extraction that runs before a site
+// extractor: OpenGraph title and description, falling back to the <title>
+// and description meta tags.
+func genericMeta(doc *goquery.Selection) PageMeta {
+	return PageMeta{
+		Title:       cleanText(firstNonEmpty(metaAttr(doc, `meta[property="og:title"]`), doc.Find("title").First().Text())),
+		Description: strings.TrimSpace(firstNonEmpty(metaAttr(doc, `meta[property="og:description"]`), metaAttr(doc, `meta[name="description"]`))),
+	}
+}
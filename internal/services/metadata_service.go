@@ -1,70 +1,155 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/netguard"
+	"github.com/robstave/link-manager/internal/platform/readability"
+	"github.com/robstave/link-manager/internal/repositories"
 )
 
-type MetadataService struct{}
+type MetadataService struct {
+	fetcher     *fetch.Client
+	guard       *netguard.Guard
+	cache       *repositories.MetadataCacheRepository
+	ttl         time.Duration
+	negativeTTL time.Duration
+	extractors  *ExtractorRegistry
+	maxDocument int64
+	batch       batchLimits
+}
 
-func NewMetadataService() *MetadataService {
-	return &MetadataService{}
+// NewMetadataService returns a metadata fetcher backed by the Postgres URL
+// cache, which may be nil. Every fetch goes through fetcher, which refuses
+// internal destinations and honours robots.txt and per-host rate limits.
+// Site-specific extractors start with the built-ins; see RegisterExtractor.
+// Limits come from the environment:
+//
+//	META_CACHE_TTL           reuse of a successful fetch (default 24h)
+//	META_CACHE_NEGATIVE_TTL  reuse of a failed fetch (default 15m)
+//	META_MAX_DOCUMENT_BYTES  size cap for a fetched page or file, raised
+//	                         above the guard's so PDFs fit (default 20 MiB)
+//	META_BATCH_MAX_URLS      URLs accepted by PreviewBatch (default 50)
+//	META_BATCH_WORKERS       batch URLs fetched at once (default 8)
+//	META_BATCH_PER_HOST      batch URLs fetched at once per host (default 2)
+func NewMetadataService(fetcher *fetch.Client, cache *repositories.MetadataCacheRepository) *MetadataService {
+	return &MetadataService{
+		fetcher:     fetcher,
+		guard:       fetcher.Guard(),
+		cache:       cache,
+		ttl:         envDuration("META_CACHE_TTL", 24*time.Hour),
+		negativeTTL: envDuration("META_CACHE_NEGATIVE_TTL", 15*time.Minute),
+		extractors:  NewExtractorRegistry(),
+		maxDocument: envBytes("META_MAX_DOCUMENT_BYTES", 20<<20),
+		batch: batchLimits{
+			maxURLs: envInt("META_BATCH_MAX_URLS", 50),
+			workers: max(1, envInt("META_BATCH_WORKERS", 8)),
+			perHost: max(1, envInt("META_BATCH_PER_HOST", 2)),
+		},
+	}
 }
 
 // PageMeta holds scraped metadata from a web page
 type PageMeta struct {
-	Title       string
-	Description string
-	IconURL     string
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	IconURL         string            `json:"icon_url"`
+	SiteName        string            `json:"site_name,omitempty"`
+	Author          string            `json:"author,omitempty"`
+	PublishedAt     *time.Time        `json:"published_at,omitempty"`
+	ContentType     string            `json:"content_type,omitempty"`
+	DurationSeconds *int              `json:"duration_seconds,omitempty"`
+	ImageURL        string            `json:"image_url,omitempty"`
+	SiteData        map[string]string `json:"site_data,omitempty"`
+	ContentText     string            `json:"content_text,omitempty"`
+	MimeType        string            `json:"mime_type,omitempty"`
+	ContentLength   *int64            `json:"content_length,omitempty"`
+	FinalURL        string            `json:"final_url,omitempty"`
+	Redirects       []Redirect        `json:"redirects,omitempty"`
+	CanonicalURL    string            `json:"canonical_url,omitempty"`
+	Language        string            `json:"language,omitempty"`
+	StatusCode      int               `json:"status_code,omitempty"`
+}
+
+// Redirect is one hop of a redirect chain: URL answered with StatusCode and
+// sent the client on.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
 }
 
-// FetchPageMeta fetches a URL and extracts the title, description and icon using Colly.
-func (s *MetadataService) FetchPageMeta(rawURL string) (PageMeta, error) {
-	slog.Info("meta: fetching page metadata", "url", rawURL)
+// statusError is returned when the page answered with an error status. page
+// holds what is known without a body: the final URL, the redirects that led
+// there and the status.
+type statusError struct {
+	page PageMeta
+}
 
-	isYouTube := strings.Contains(strings.ToLower(rawURL), "youtube.com") ||
-		strings.Contains(strings.ToLower(rawURL), "youtu.be")
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d %s", e.page.StatusCode, http.StatusText(e.page.StatusCode))
+}
+
+// scrapeResult carries HTTP caching details of a scrape.
+type scrapeResult struct {
+	notModified  bool
+	etag         string
+	lastModified string
+}
+
+// scrapePageMeta fetches a URL with Colly and describes it. For HTML that is
+// the title, description and icon, structured metadata from OpenGraph,
+// schema.org JSON-LD and any advertised oEmbed endpoint, refinements from an
+// extractor registered for the host, and the readable main text for search.
+// PDFs, images and text files are described from their content instead; see
+// describeDocument. When validators from a previous fetch are passed and the
+// origin answers 304, it returns with notModified set and an empty PageMeta.
+func (s *MetadataService) scrapePageMeta(ctx context.Context, rawURL string, etag, lastModified string) (PageMeta, scrapeResult, error) {
+	slog.Info("meta: fetching page metadata", "url", rawURL, "conditional", etag != "" || lastModified != "")
 
 	var meta PageMeta
+	var sm structuredMeta
+	var result scrapeResult
 	baseURL := rawURL
 
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		colly.MaxDepth(1),
-	)
-	c.SetRequestTimeout(10 * time.Second)
+	c := s.newCollector(netguard.WithMaxBodyBytes(ctx, s.maxDocument), s.maxDocument, 30*time.Second)
+	isHTML := true
+	var contentLanguage string
 
-	// ── Title extraction ─────────────────────────────────────────────────────
+	c.SetRedirectHandler(func(req *http.Request, via []*http.Request) error {
+		if err := s.guard.CheckRedirect(req, via); err != nil {
+			return err
+		}
+		hop := Redirect{URL: via[len(via)-1].URL.String()}
+		if req.Response != nil {
+			hop.StatusCode = req.Response.StatusCode
+		}
+		meta.Redirects = append(meta.Redirects, hop)
+		return nil
+	})
 
-	// For YouTube, prefer twitter:title (clean, no channel prefix or " - YouTube" suffix)
-	if isYouTube {
-		c.OnHTML(`meta[name="twitter:title"]`, func(e *colly.HTMLElement) {
-			if meta.Title == "" {
-				val := strings.TrimSpace(e.Attr("content"))
-				if val != "" {
-					slog.Info("meta: youtube twitter:title hit", "value", val)
-					meta.Title = cleanText(val)
-				}
-			}
-		})
-		c.OnHTML(`title`, func(e *colly.HTMLElement) {
-			if meta.Title == "" {
-				val := strings.TrimSpace(e.Text)
-				if val != "" {
-					slog.Info("meta: youtube <title> hit", "value", val)
-					meta.Title = cleanText(val)
-				}
-			}
-		})
-	}
+	c.OnRequest(func(r *colly.Request) {
+		if etag != "" {
+			r.Headers.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			r.Headers.Set("If-Modified-Since", lastModified)
+		}
+	})
+
+	// ── Title extraction ─────────────────────────────────────────────────────
 
-	// General: og:title first, then twitter:title, then <title>
+	// og:title first, then twitter:title, then <title>
 	c.OnHTML(`meta[property="og:title"]`, func(e *colly.HTMLElement) {
 		if meta.Title == "" {
 			val := strings.TrimSpace(e.Attr("content"))
@@ -153,40 +238,219 @@ func (s *MetadataService) FetchPageMeta(rawURL string) (PageMeta, error) {
 		}
 	})
 
+	// ── Structured metadata ───────────────────────────────────────────────────
+
+	metaContent := func(selector string, apply func(val string)) {
+		c.OnHTML(selector, func(e *colly.HTMLElement) {
+			if val := strings.TrimSpace(e.Attr("content")); val != "" {
+				apply(val)
+			}
+		})
+	}
+	metaContent(`meta[property="og:image"]`, func(val string) {
+		if meta.ImageURL == "" {
+			meta.ImageURL = resolveURL(val, baseURL)
+		}
+	})
+	metaContent(`meta[property="og:site_name"]`, func(val string) {
+		if meta.SiteName == "" {
+			meta.SiteName = cleanText(val)
+		}
+	})
+	metaContent(`meta[name="author"], meta[property="article:author"]`, func(val string) {
+		// article:author is often a profile URL rather than a name.
+		if meta.Author == "" && !strings.HasPrefix(val, "http") {
+			meta.Author = cleanText(val)
+		}
+	})
+	metaContent(`meta[property="article:published_time"], meta[itemprop="datePublished"]`, func(val string) {
+		if meta.PublishedAt == nil {
+			meta.PublishedAt = parseMetaTime(val)
+		}
+	})
+	metaContent(`meta[property="og:video:duration"], meta[property="video:duration"], meta[itemprop="duration"]`, func(val string) {
+		if meta.DurationSeconds == nil {
+			meta.DurationSeconds = parseISODuration(val)
+		}
+	})
+	metaContent(`meta[property="og:type"]`, func(val string) {
+		sm.ogType = val
+	})
+	c.OnHTML(`link[rel="alternate"][type="application/json+oembed"]`, func(e *colly.HTMLElement) {
+		if href := strings.TrimSpace(e.Attr("href")); href != "" && sm.oembedURL == "" {
+			sm.oembedURL = resolveURL(href, baseURL)
+		}
+	})
+	c.OnHTML(`script[type="application/ld+json"]`, func(e *colly.HTMLElement) {
+		sm.jsonLD = append(sm.jsonLD, e.Text)
+	})
+	c.OnHTML(`link[rel="canonical"]`, func(e *colly.HTMLElement) {
+		if href := strings.TrimSpace(e.Attr("href")); href != "" && meta.CanonicalURL == "" {
+			meta.CanonicalURL = resolveURL(href, baseURL)
+		}
+	})
+	metaContent(`meta[http-equiv="content-language" i], meta[property="og:locale"]`, func(val string) {
+		if contentLanguage == "" {
+			contentLanguage = val
+		}
+	})
+
+	var doc *goquery.Selection
+	c.OnHTML(`html`, func(e *colly.HTMLElement) {
+		doc = e.DOM
+		meta.Language = normalizeLanguage(e.Attr("lang"))
+	})
+
+	// Colly reports every status from 203 up, other than redirects, as an error.
+	var errorPage *PageMeta
 	c.OnError(func(r *colly.Response, err error) {
+		if r.StatusCode == http.StatusNotModified {
+			result.notModified = true
+			return
+		}
+		if r.StatusCode != 0 {
+			errorPage = &PageMeta{FinalURL: r.Request.URL.String(), Redirects: meta.Redirects, StatusCode: r.StatusCode}
+		}
 		slog.Error("meta: colly request error", "url", rawURL, "status", r.StatusCode, "error", err)
 	})
 
 	c.OnResponse(func(r *colly.Response) {
 		slog.Info("meta: response received", "url", rawURL, "status", r.StatusCode, "bytes", len(r.Body))
+		// Relative links resolve against where we ended up.
+		baseURL = r.Request.URL.String()
+		meta.FinalURL = baseURL
+		meta.StatusCode = r.StatusCode
+		header := http.Header{}
+		if r.Headers != nil {
+			header = *r.Headers
+			result.etag = header.Get("ETag")
+			result.lastModified = header.Get("Last-Modified")
+		}
+		contentLanguage = header.Get("Content-Language")
+		meta.MimeType = responseMIME(header, r.Body)
+		length := responseLength(header, r.Body)
+		meta.ContentLength = &length
+		if !isHTMLMIME(meta.MimeType) {
+			isHTML = false
+			describeDocument(&meta, r.Request.URL.String(), meta.MimeType, r.Body)
+		}
 	})
 
 	if err := c.Visit(rawURL); err != nil {
+		if result.notModified {
+			slog.Info("meta: not modified", "url", rawURL)
+			return PageMeta{}, result, nil
+		}
 		slog.Error("meta: colly visit failed", "url", rawURL, "error", err)
-		return PageMeta{}, fmt.Errorf("failed to fetch URL: %w", err)
+		if errorPage != nil {
+			err = &statusError{page: *errorPage}
+		}
+		return PageMeta{}, result, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 
-	// Final fallback for icon: /favicon.ico at domain root
-	if meta.IconURL == "" {
-		parsed, err := url.Parse(rawURL)
-		if err == nil {
-			meta.IconURL = parsed.Scheme + "://" + parsed.Host + "/favicon.ico"
-		}
+	if meta.Language == "" {
+		meta.Language = normalizeLanguage(contentLanguage)
+	}
+	if !isHTML {
+		slog.Info("meta: described document", "url", rawURL, "mimeType", meta.MimeType, "bytes", len(meta.ContentText), "title", meta.Title)
+		return meta, result, nil
 	}
 
-	// Known-site icon overrides (sites with non-standard or broken favicon discovery)
-	if strings.Contains(strings.ToLower(rawURL), "leetcode.com") {
-		slog.Info("meta: leetcode icon override", "url", rawURL)
-		meta.IconURL = "https://assets.leetcode.com/static_assets/public/icons/favicon.ico"
+	applyJSONLD(&meta, &sm, sm.jsonLD, baseURL)
+	if sm.oembedURL != "" {
+		s.applyOEmbed(ctx, &meta, &sm, sm.oembedURL)
+	}
+	meta.ContentType = resolveContentType(sm, baseURL)
+	if page, err := url.Parse(baseURL); err == nil && doc != nil {
+		if ex := s.extractors.For(page); ex != nil {
+			slog.Info("meta: site extractor", "url", rawURL, "extractor", ex.Name())
+			ex.Extract(page, doc, &meta)
+		}
 	}
+	if doc != nil {
+		meta.ContentText = readability.Extract(doc)
+	}
+
+	slog.Info("meta: extracted metadata", "url", rawURL, "title", meta.Title, "description", meta.Description, "iconURL", meta.IconURL,
+		"contentType", meta.ContentType, "siteName", meta.SiteName, "author", meta.Author, "contentBytes", len(meta.ContentText))
+	return meta, result, nil
+}
+
+// LinkPreview is what the Add Link form needs to prefill a link: the page's
+// metadata plus where the URL actually leads.
+type LinkPreview struct {
+	URL             string            `json:"url"`
+	FinalURL        string            `json:"final_url"`
+	Redirected      bool              `json:"redirected"`
+	CrossSite       bool              `json:"cross_site"`
+	Redirects       []Redirect        `json:"redirects"`
+	CanonicalURL    string            `json:"canonical_url,omitempty"`
+	StatusCode      int               `json:"status_code"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	IconURL         string            `json:"icon_url"`
+	SiteName        string            `json:"site_name,omitempty"`
+	ImageURL        string            `json:"image_url,omitempty"`
+	Language        string            `json:"language,omitempty"`
+	ContentType     string            `json:"content_type"`
+	MimeType        string            `json:"mime_type,omitempty"`
+	ContentLength   *int64            `json:"content_length,omitempty"`
+	Author          string            `json:"author,omitempty"`
+	PublishedAt     *time.Time        `json:"published_at,omitempty"`
+	DurationSeconds *int              `json:"duration_seconds,omitempty"`
+	SiteData        map[string]string `json:"site_data,omitempty"`
+}
 
-	slog.Info("meta: extracted metadata", "url", rawURL, "title", meta.Title, "description", meta.Description, "iconURL", meta.IconURL)
-	return meta, nil
+// Preview fetches (or reads from cache) a URL's metadata for display before
+// the link is saved. CrossSite is set when redirects end on another
+// registrable domain, which usually means a shortener or a moved page. A page
+// answering with an error status is still previewed, with that StatusCode
+// and no page metadata.
+func (s *MetadataService) Preview(ctx context.Context, rawURL string) (LinkPreview, error) {
+	rawURL = normalizeURL(rawURL)
+	meta, err := s.FetchPageMeta(ctx, rawURL)
+	var se *statusError
+	if errors.As(err, &se) {
+		meta, err = se.page, nil
+	}
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	finalURL := meta.FinalURL
+	if finalURL == "" {
+		finalURL = rawURL
+	}
+	redirects := meta.Redirects
+	if redirects == nil {
+		redirects = []Redirect{}
+	}
+	return LinkPreview{
+		URL:             rawURL,
+		FinalURL:        finalURL,
+		Redirected:      finalURL != rawURL,
+		CrossSite:       RegistrableDomain(finalURL) != RegistrableDomain(rawURL),
+		Redirects:       redirects,
+		CanonicalURL:    meta.CanonicalURL,
+		StatusCode:      meta.StatusCode,
+		Title:           meta.Title,
+		Description:     meta.Description,
+		IconURL:         meta.IconURL,
+		SiteName:        meta.SiteName,
+		ImageURL:        meta.ImageURL,
+		Language:        meta.Language,
+		ContentType:     meta.ContentType,
+		MimeType:        meta.MimeType,
+		ContentLength:   meta.ContentLength,
+		Author:          meta.Author,
+		PublishedAt:     meta.PublishedAt,
+		DurationSeconds: meta.DurationSeconds,
+		SiteData:        meta.SiteData,
+	}, nil
 }
 
 // FetchTitle fetches a URL and extracts the title (kept for backward compat)
-func (s *MetadataService) FetchTitle(rawURL string) (string, error) {
-	meta, err := s.FetchPageMeta(rawURL)
+func (s *MetadataService) FetchTitle(ctx context.Context, rawURL string) (string, error) {
+	meta, err := s.FetchPageMeta(ctx, rawURL)
 	if err != nil {
 		return "", err
 	}
@@ -196,6 +460,57 @@ func (s *MetadataService) FetchTitle(rawURL string) (string, error) {
 	return meta.Title, nil
 }
 
+// FetchPageText returns a page's readable main text, or, when none was found,
+// the visible body text with scripts, styles and page chrome removed. Used as
+// input for generated notes.
+func (s *MetadataService) FetchPageText(ctx context.Context, rawURL string) (string, error) {
+	if meta, err := s.FetchPageMeta(ctx, rawURL); err == nil && meta.ContentText != "" {
+		return meta.ContentText, nil
+	}
+	var text string
+
+	c := s.newCollector(ctx, s.guard.MaxBodyBytes(), 10*time.Second)
+
+	c.OnHTML(`body`, func(e *colly.HTMLElement) {
+		body := e.DOM.Clone()
+		body.Find(`script, style, noscript, nav, header, footer, aside, form, svg`).Remove()
+		// Separate block elements so sentences from adjacent blocks don't run together.
+		body.Find(`p, li, h1, h2, h3, h4, h5, h6, blockquote, pre, td, div`).Each(func(_ int, sel *goquery.Selection) {
+			sel.AppendHtml(" \n")
+		})
+		text = cleanText(body.Text())
+	})
+
+	if err := c.Visit(rawURL); err != nil {
+		slog.Error("meta: page text fetch failed", "url", rawURL, "error", err)
+		return "", fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	return text, nil
+}
+
+// normalizeLanguage turns a lang attribute, Content-Language header or
+// og:locale into a single BCP 47 tag: "en_US" and "en-US, fr" become "en-US".
+func normalizeLanguage(v string) string {
+	v, _, _ = strings.Cut(v, ",")
+	return strings.ReplaceAll(strings.TrimSpace(v), "_", "-")
+}
+
+// newCollector returns a single-page collector whose requests, including
+// redirects, go through the shared fetch client. maxBytes should match the
+// guard's cap for ctx.
+func (s *MetadataService) newCollector(ctx context.Context, maxBytes int64, timeout time.Duration) *colly.Collector {
+	c := colly.NewCollector(
+		colly.UserAgent(s.fetcher.UserAgent()),
+		colly.MaxDepth(1),
+		colly.StdlibContext(ctx),
+		colly.MaxBodySize(int(maxBytes)),
+	)
+	c.SetRequestTimeout(timeout)
+	c.WithTransport(s.fetcher.Transport())
+	c.SetRedirectHandler(s.guard.CheckRedirect)
+	return c
+}
+
 // resolveURL turns relative hrefs into absolute URLs
 func resolveURL(href, base string) string {
 	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
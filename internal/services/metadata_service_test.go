@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/netguard"
+)
+
+// Error pages are previewed with their status; enrichment still fails on them.
+func TestPreviewReportsErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/missing", http.StatusMovedPermanently)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<html><head><title>Not Found</title></head></html>")
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/broken":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			io.WriteString(w, "<html><head><title>Home</title></head></html>")
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	guard := netguard.NewGuard(netguard.Options{Deny: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Ports: []int{port}})
+	svc := NewMetadataService(fetch.NewClient(guard, fetch.Options{IgnoreRobots: true, RatePerHost: 100}), nil)
+	ctx := context.Background()
+
+	for path, want := range map[string]int{"/": 200, "/missing": 404, "/gone": 410, "/broken": 503} {
+		preview, err := svc.Preview(ctx, srv.URL+path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if preview.StatusCode != want {
+			t.Errorf("%s: status_code = %d, want %d", path, preview.StatusCode, want)
+		}
+		if want != 200 && preview.Title != "" {
+			t.Errorf("%s: title %q from an error page", path, preview.Title)
+		}
+	}
+
+	preview, err := svc.Preview(ctx, srv.URL+"/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.StatusCode != 404 || preview.FinalURL != srv.URL+"/missing" || len(preview.Redirects) != 1 {
+		t.Errorf("redirect to 404: got status %d, final URL %s, %d redirects", preview.StatusCode, preview.FinalURL, len(preview.Redirects))
+	}
+
+	if _, err := svc.FetchPageMeta(ctx, srv.URL+"/missing"); err == nil {
+		t.Error("FetchPageMeta succeeded on a 404")
+	}
+}
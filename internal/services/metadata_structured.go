@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content types recorded on links. Anything not recognised is ContentTypeWebsite.
+const (
+	ContentTypeArticle  = "article"
+	ContentTypeVideo    = "video"
+	ContentTypeRepo     = "repo"
+	ContentTypePaper    = "paper"
+	ContentTypeImage    = "image"
+	ContentTypeDocument = "document"
+	ContentTypeWebsite  = "website"
+)
+
+func ValidContentType(t string) bool {
+	switch t {
+	case ContentTypeArticle, ContentTypeVideo, ContentTypeRepo, ContentTypePaper, ContentTypeImage, ContentTypeDocument, ContentTypeWebsite:
+		return true
+	}
+	return false
+}
+
+// structuredMeta collects the typed signals found while scraping a page so
+// that FetchPageMeta can resolve them in priority order once the visit ends.
+type structuredMeta struct {
+	ogType     string
+	oembedURL  string
+	jsonLD     []string
+	ldType     string
+	oembedType string
+}
+
+// schema.org types mapped onto our content types. WebPage and WebSite are
+// deliberately absent: nearly every page declares them.
+var jsonLDContentTypes = map[string]string{
+	"Article":                 ContentTypeArticle,
+	"NewsArticle":             ContentTypeArticle,
+	"BlogPosting":             ContentTypeArticle,
+	"TechArticle":             ContentTypeArticle,
+	"Report":                  ContentTypeArticle,
+	"VideoObject":             ContentTypeVideo,
+	"Movie":                   ContentTypeVideo,
+	"Episode":                 ContentTypeVideo,
+	"SoftwareSourceCode":      ContentTypeRepo,
+	"ScholarlyArticle":        ContentTypePaper,
+	"MedicalScholarlyArticle": ContentTypePaper,
+}
+
+// applyJSONLD fills empty fields of meta from schema.org JSON-LD blocks.
+// The first node with a recognised type is treated as the page's subject;
+// WebSite nodes only contribute the site name.
+func applyJSONLD(meta *PageMeta, sm *structuredMeta, blocks []string, baseURL string) {
+	var nodes []map[string]any
+	for _, block := range blocks {
+		var v any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(block)), &v); err != nil {
+			slog.Warn("meta: invalid JSON-LD block", "url", baseURL, "error", err)
+			continue
+		}
+		nodes = append(nodes, flattenJSONLD(v)...)
+	}
+
+	for _, node := range nodes {
+		for _, t := range ldTypes(node) {
+			if t == "WebSite" && meta.SiteName == "" {
+				meta.SiteName = cleanText(ldString(node["name"]))
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		contentType := ""
+		for _, t := range ldTypes(node) {
+			if ct, ok := jsonLDContentTypes[t]; ok {
+				contentType = ct
+				break
+			}
+		}
+		if contentType == "" {
+			continue
+		}
+		slog.Info("meta: JSON-LD subject found", "url", baseURL, "type", contentType)
+		sm.ldType = contentType
+		if meta.Title == "" {
+			meta.Title = cleanText(firstNonEmpty(ldString(node["headline"]), ldString(node["name"])))
+		}
+		if meta.Description == "" {
+			meta.Description = cleanText(firstNonEmpty(ldString(node["description"]), ldString(node["abstract"])))
+		}
+		if meta.Author == "" {
+			meta.Author = ldNames(node["author"])
+		}
+		if meta.Author == "" {
+			meta.Author = ldNames(node["creator"])
+		}
+		if meta.SiteName == "" {
+			meta.SiteName = ldNames(node["publisher"])
+		}
+		if meta.PublishedAt == nil {
+			meta.PublishedAt = parseMetaTime(firstNonEmpty(ldString(node["datePublished"]), ldString(node["uploadDate"]), ldString(node["dateCreated"])))
+		}
+		if meta.DurationSeconds == nil {
+			meta.DurationSeconds = parseISODuration(ldString(node["duration"]))
+		}
+		if meta.ImageURL == "" {
+			if img := firstNonEmpty(ldString(node["thumbnailUrl"]), ldString(node["image"])); img != "" {
+				meta.ImageURL = resolveURL(img, baseURL)
+			}
+		}
+		return
+	}
+}
+
+// flattenJSONLD returns every object node in a JSON-LD document, expanding
+// top-level arrays and @graph containers.
+func flattenJSONLD(v any) []map[string]any {
+	switch t := v.(type) {
+	case []any:
+		var out []map[string]any
+		for _, item := range t {
+			out = append(out, flattenJSONLD(item)...)
+		}
+		return out
+	case map[string]any:
+		out := []map[string]any{t}
+		if graph, ok := t["@graph"]; ok {
+			out = append(out, flattenJSONLD(graph)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func ldTypes(node map[string]any) []string {
+	switch t := node["@type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// ldString reads a JSON-LD value that may be a string, an object with a url
+// or name, or an array of either; the first usable string wins.
+func ldString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case map[string]any:
+		return firstNonEmpty(ldString(t["url"]), ldString(t["contentUrl"]), ldString(t["name"]), ldString(t["@value"]))
+	case []any:
+		for _, item := range t {
+			if s := ldString(item); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// ldNames joins the names of a person/organisation value, e.g. several authors.
+func ldNames(v any) string {
+	switch t := v.(type) {
+	case string:
+		return cleanText(t)
+	case map[string]any:
+		return cleanText(ldString(t["name"]))
+	case []any:
+		var names []string
+		for _, item := range t {
+			if n := ldNames(item); n != "" {
+				names = append(names, n)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+type oembedResponse struct {
+	Type         string          `json:"type"`
+	Title        string          `json:"title"`
+	AuthorName   string          `json:"author_name"`
+	ProviderName string          `json:"provider_name"`
+	ThumbnailURL string          `json:"thumbnail_url"`
+	Duration     json.RawMessage `json:"duration"`
+}
+
+// applyOEmbed fetches the page's advertised oEmbed endpoint and fills any
+// fields still empty. Failures are logged; oEmbed is only ever a bonus. The
+// fetch is bounded by ctx as well as its own timeout.
+func (s *MetadataService) applyOEmbed(ctx context.Context, meta *PageMeta, sm *structuredMeta, endpoint string) {
+	client := s.fetcher.HTTPClient(5 * time.Second)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Warn("meta: oEmbed fetch failed", "endpoint", endpoint, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Warn("meta: oEmbed fetch failed", "endpoint", endpoint, "status", resp.StatusCode)
+		return
+	}
+	var o oembedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&o); err != nil {
+		slog.Warn("meta: invalid oEmbed response", "endpoint", endpoint, "error", err)
+		return
+	}
+	slog.Info("meta: oEmbed hit", "endpoint", endpoint, "type", o.Type, "provider", o.ProviderName)
+
+	if o.Type == "video" {
+		sm.oembedType = ContentTypeVideo
+	}
+	if meta.Title == "" {
+		meta.Title = cleanText(o.Title)
+	}
+	if meta.Author == "" {
+		meta.Author = cleanText(o.AuthorName)
+	}
+	if meta.SiteName == "" {
+		meta.SiteName = cleanText(o.ProviderName)
+	}
+	if meta.ImageURL == "" {
+		meta.ImageURL = o.ThumbnailURL
+	}
+	if meta.DurationSeconds == nil && len(o.Duration) > 0 {
+		var secs float64
+		if json.Unmarshal(o.Duration, &secs) == nil && secs > 0 {
+			d := int(secs)
+			meta.DurationSeconds = &d
+		}
+	}
+}
+
+// resolveContentType picks the most specific signal: a JSON-LD subject type,
+// then an oEmbed video, then og:type, then well-known URL shapes.
+func resolveContentType(sm structuredMeta, rawURL string) string {
+	if sm.ldType != "" {
+		return sm.ldType
+	}
+	if sm.oembedType != "" {
+		return sm.oembedType
+	}
+	og := strings.ToLower(sm.ogType)
+	switch {
+	case og == "article":
+		return ContentTypeArticle
+	case strings.HasPrefix(og, "video"):
+		return ContentTypeVideo
+	}
+	if t := contentTypeFromURL(rawURL); t != "" {
+		return t
+	}
+	return ContentTypeWebsite
+}
+
+var repoHosts = map[string]bool{"github.com": true, "gitlab.com": true, "codeberg.org": true, "bitbucket.org": true}
+
+func contentTypeFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.ToLower(u.Path)
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	switch {
+	case repoHosts[host] && len(segments) == 2:
+		return ContentTypeRepo
+	case host == "arxiv.org" && len(segments) > 0 && (segments[0] == "abs" || segments[0] == "pdf"),
+		host == "doi.org", strings.HasSuffix(path, ".pdf"):
+		return ContentTypePaper
+	case host == "youtube.com" && (path == "/watch" || strings.HasPrefix(path, "/shorts/")),
+		host == "youtu.be", host == "vimeo.com" && len(segments) == 1:
+		return ContentTypeVideo
+	}
+	return ""
+}
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISODuration parses schema.org durations such as PT1H2M3S. Plain
+// integers are taken as seconds, as used by og:video:duration.
+func parseISODuration(s string) *int {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return &n
+	}
+	m := isoDurationRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	total := 0.0
+	for i, unit := range []float64{86400, 3600, 60, 1} {
+		if m[i+1] != "" {
+			v, _ := strconv.ParseFloat(m[i+1], 64)
+			total += v * unit
+		}
+	}
+	if total <= 0 {
+		return nil
+	}
+	secs := int(total)
+	return &secs
+}
+
+var metaTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", "2006/01/02"}
+
+func parseMetaTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range metaTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	slog.Warn("meta: unparsed publish date", "value", s)
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
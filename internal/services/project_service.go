@@ -11,16 +11,46 @@ import (
 var ErrDefaultProjectDelete = errors.New("cannot delete default project")
 
 type ProjectService struct {
-	repo *repositories.ProjectRepository
+	repo        *repositories.ProjectRepository
+	collections *SmartCollectionService
 }
 
-func NewProjectService(repo *repositories.ProjectRepository) *ProjectService {
-	return &ProjectService{repo: repo}
+func NewProjectService(repo *repositories.ProjectRepository, collections *SmartCollectionService) *ProjectService {
+	return &ProjectService{repo: repo, collections: collections}
 }
 
 func (s *ProjectService) List(ctx context.Context, ownerID string) ([]repositories.ProjectWithCounts, error) {
 	return s.repo.List(ctx, ownerID)
 }
+
+// ListWithCollections appends the user's smart collections as virtual folders
+// after the real projects, with their live link counts.
+func (s *ProjectService) ListWithCollections(ctx context.Context, ownerID string) ([]repositories.ProjectWithCounts, error) {
+	projects, err := s.repo.List(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := s.collections.List(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range collections {
+		projects = append(projects, repositories.ProjectWithCounts{
+			Project: models.Project{
+				ID:           sc.ID,
+				OwnerID:      sc.OwnerID,
+				Name:         sc.Name,
+				Description:  sc.Description,
+				DisplayOrder: sc.DisplayOrder,
+				CreatedAt:    sc.CreatedAt,
+				UpdatedAt:    sc.UpdatedAt,
+			},
+			LinkCount: sc.LinkCount,
+			Virtual:   true,
+		})
+	}
+	return projects, nil
+}
 func (s *ProjectService) Create(ctx context.Context, ownerID, name, description string) (models.Project, error) {
 	return s.repo.Create(ctx, ownerID, name, description)
 }
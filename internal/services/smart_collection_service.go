@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+var ErrInvalidSmartFilter = errors.New("invalid smart collection filter")
+
+type SmartCollectionService struct {
+	repo     *repositories.SmartCollectionRepository
+	linkRepo *repositories.LinkRepository
+}
+
+func NewSmartCollectionService(repo *repositories.SmartCollectionRepository, linkRepo *repositories.LinkRepository) *SmartCollectionService {
+	return &SmartCollectionService{repo: repo, linkRepo: linkRepo}
+}
+
+// SmartCollectionWithCount carries the live number of matching links, used as
+// the sidebar count badge.
+type SmartCollectionWithCount struct {
+	models.SmartCollection
+	LinkCount int `json:"link_count"`
+}
+
+func (s *SmartCollectionService) List(ctx context.Context, ownerID string) ([]SmartCollectionWithCount, error) {
+	collections, err := s.repo.List(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]SmartCollectionWithCount, 0, len(collections))
+	for _, sc := range collections {
+		count, err := s.linkRepo.Count(ctx, ownerID, ToLinkFilters(sc.Filters, now))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, SmartCollectionWithCount{SmartCollection: sc, LinkCount: count})
+	}
+	return result, nil
+}
+
+func (s *SmartCollectionService) Get(ctx context.Context, ownerID, id string) (SmartCollectionWithCount, error) {
+	sc, err := s.repo.Get(ctx, id, ownerID)
+	if err != nil {
+		return SmartCollectionWithCount{}, err
+	}
+	count, err := s.linkRepo.Count(ctx, ownerID, ToLinkFilters(sc.Filters, time.Now()))
+	if err != nil {
+		return SmartCollectionWithCount{}, err
+	}
+	return SmartCollectionWithCount{SmartCollection: sc, LinkCount: count}, nil
+}
+
+func (s *SmartCollectionService) Create(ctx context.Context, ownerID, name, description string, filters models.SmartFilter) (models.SmartCollection, error) {
+	if err := validateSmartFilter(&filters); err != nil {
+		return models.SmartCollection{}, err
+	}
+	return s.repo.Create(ctx, ownerID, name, description, filters)
+}
+
+func (s *SmartCollectionService) Update(ctx context.Context, ownerID, id, name, description string, filters models.SmartFilter) (models.SmartCollection, error) {
+	if err := validateSmartFilter(&filters); err != nil {
+		return models.SmartCollection{}, err
+	}
+	return s.repo.Update(ctx, id, ownerID, name, description, filters)
+}
+
+func (s *SmartCollectionService) Delete(ctx context.Context, ownerID, id string) error {
+	return s.repo.Delete(ctx, id, ownerID)
+}
+
+// Links evaluates the collection live through LinkRepository.List. A non-empty
+// sortBy overrides the collection's saved sort order.
+func (s *SmartCollectionService) Links(ctx context.Context, ownerID, id, sortBy string, limit, offset int) ([]repositories.LinkWithMeta, int, error) {
+	sc, err := s.repo.Get(ctx, id, ownerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	f := ToLinkFilters(sc.Filters, time.Now())
+	if sortBy != "" {
+		f.SortBy = sortBy
+	}
+	f.Limit, f.Offset = limit, offset
+	return s.linkRepo.List(ctx, ownerID, f)
+}
+
+// ToLinkFilters resolves a saved filter's relative day windows against now.
+func ToLinkFilters(sf models.SmartFilter, now time.Time) repositories.LinkFilters {
+	daysAgo := func(days *int) *time.Time {
+		if days == nil {
+			return nil
+		}
+		t := now.AddDate(0, 0, -*days)
+		return &t
+	}
+	f := repositories.LinkFilters{
+		ProjectID:       sf.ProjectID,
+		CategoryID:      sf.CategoryID,
+		Tag:             sf.Tag,
+		Cart:            sf.Cart,
+		Domain:          sf.Domain,
+		ContentType:     sf.ContentType,
+		Search:          sf.Search,
+		SortBy:          sf.SortBy,
+		MinStars:        sf.MinStars,
+		MaxStars:        sf.MaxStars,
+		MinClicks:       sf.MinClicks,
+		MaxClicks:       sf.MaxClicks,
+		CreatedAfter:    daysAgo(sf.AddedWithinDays),
+		CreatedBefore:   daysAgo(sf.AddedBeforeDays),
+		ClickedSince:    daysAgo(sf.VisitedWithinDays),
+		NotClickedSince: daysAgo(sf.NotVisitedDays),
+		TagsAll:         normalizeFilterTags(sf.TagsAll),
+		TagsAny:         sf.TagsAny,
+		TagsNone:        sf.TagsNone,
+	}
+	if f.SortBy == "" {
+		f.SortBy = "stars"
+		if f.Search != "" {
+			f.SortBy = "relevance"
+		}
+	}
+	return f
+}
+
+// validateSmartFilter checks f and normalises its tag lists in place.
+func validateSmartFilter(f *models.SmartFilter) error {
+	inRange := func(v *int, min, max int) bool { return v == nil || (*v >= min && *v <= max) }
+	if !inRange(f.MinStars, 0, 10) || !inRange(f.MaxStars, 0, 10) {
+		return errors.Join(ErrInvalidSmartFilter, errors.New("stars must be between 0 and 10"))
+	}
+	if f.MinStars != nil && f.MaxStars != nil && *f.MinStars > *f.MaxStars {
+		return errors.Join(ErrInvalidSmartFilter, errors.New("min_stars exceeds max_stars"))
+	}
+	if f.MinClicks != nil && f.MaxClicks != nil && *f.MinClicks > *f.MaxClicks {
+		return errors.Join(ErrInvalidSmartFilter, errors.New("min_clicks exceeds max_clicks"))
+	}
+	for _, v := range []*int{f.MinClicks, f.MaxClicks, f.AddedWithinDays, f.AddedBeforeDays, f.VisitedWithinDays, f.NotVisitedDays} {
+		if v != nil && *v < 0 {
+			return errors.Join(ErrInvalidSmartFilter, errors.New("click thresholds and day windows must not be negative"))
+		}
+	}
+	switch f.SortBy {
+	case "", "stars", "clicks", "recent", "created", "relevance":
+	default:
+		return errors.Join(ErrInvalidSmartFilter, errors.New("unknown sort: "+f.SortBy))
+	}
+	if f.ContentType != "" && !ValidContentType(f.ContentType) {
+		return errors.Join(ErrInvalidSmartFilter, errors.New("unknown content_type: "+f.ContentType))
+	}
+	switch f.Cart {
+	case "", "true", "false":
+	default:
+		return errors.Join(ErrInvalidSmartFilter, errors.New("cart must be true or false"))
+	}
+	f.TagsAll = normalizeFilterTags(f.TagsAll)
+	f.TagsAny = normalizeFilterTags(f.TagsAny)
+	f.TagsNone = normalizeFilterTags(f.TagsNone)
+	return nil
+}
+
+// normalizeFilterTags trims tag names the way links store them and drops
+// blanks and duplicates. TagsAll needs this: a link matches when its count of
+// matching tags equals the list's length, which a repeated name never does.
+// ToLinkFilters applies it too, for collections saved before validation did.
+func normalizeFilterTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/robstave/link-manager/internal/models"
+)
+
+func TestValidateSmartFilterNormalizesTags(t *testing.T) {
+	f := models.SmartFilter{
+		TagsAll:  []string{"go", " go ", "", "db", "Go"},
+		TagsAny:  []string{"a", "a"},
+		TagsNone: []string{"  "},
+	}
+	if err := validateSmartFilter(&f); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"go", "db", "Go"}; !slices.Equal(f.TagsAll, want) {
+		t.Errorf("TagsAll = %q, want %q", f.TagsAll, want)
+	}
+	if want := []string{"a"}; !slices.Equal(f.TagsAny, want) {
+		t.Errorf("TagsAny = %q, want %q", f.TagsAny, want)
+	}
+	if f.TagsNone != nil {
+		t.Errorf("TagsNone = %q, want none", f.TagsNone)
+	}
+
+	// Collections saved before normalisation still match.
+	lf := ToLinkFilters(models.SmartFilter{TagsAll: []string{"a", "a"}}, time.Now())
+	if want := []string{"a"}; !slices.Equal(lf.TagsAll, want) {
+		t.Errorf("ToLinkFilters TagsAll = %q, want %q", lf.TagsAll, want)
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/robstave/link-manager/internal/models"
+	"github.com/robstave/link-manager/internal/platform/blobstore"
+	"github.com/robstave/link-manager/internal/platform/fetch"
+	"github.com/robstave/link-manager/internal/platform/imaging"
+	"github.com/robstave/link-manager/internal/platform/netguard"
+	"github.com/robstave/link-manager/internal/repositories"
+)
+
+// ThumbnailPathPrefix is where stored thumbnails are served.
+const ThumbnailPathPrefix = "/api/v1/thumbnails/"
+
+// ThumbnailSizes maps each generated size to its longest side in pixels.
+// ThumbnailDefaultSize is served when no size is asked for.
+var ThumbnailSizes = map[string]int{"small": 160, "medium": 320, "large": 640}
+
+const ThumbnailDefaultSize = "medium"
+
+const (
+	maxThumbnailSourceBytes = 10 << 20
+	// maxThumbnailPixels refuses images that would take too much memory to
+	// decode, whatever their file size.
+	maxThumbnailPixels = 40_000_000
+	thumbnailQuality   = 82
+)
+
+var ErrInvalidThumbnailSize = errors.New("size must be small, medium or large")
+
+// ThumbnailService turns a link's preview image (og:image) into JPEG
+// thumbnails kept in a blob store.
+type ThumbnailService struct {
+	repo   *repositories.ThumbnailRepository
+	store  blobstore.Store
+	jobs   *JobQueue
+	client *http.Client
+}
+
+func NewThumbnailService(repo *repositories.ThumbnailRepository, store blobstore.Store, jobs *JobQueue, fetcher *fetch.Client) *ThumbnailService {
+	return &ThumbnailService{repo: repo, store: store, jobs: jobs, client: fetcher.HTTPClient(30 * time.Second)}
+}
+
+// Get returns a stored thumbnail at one of ThumbnailSizes.
+func (s *ThumbnailService) Get(ctx context.Context, hash, size string) ([]byte, error) {
+	if size == "" {
+		size = ThumbnailDefaultSize
+	}
+	if _, ok := ThumbnailSizes[size]; !ok {
+		return nil, ErrInvalidThumbnailSize
+	}
+	return s.store.Get(ctx, thumbnailKey(hash, size))
+}
+
+func thumbnailKey(hash, size string) string {
+	return "thumbnails/" + hash + "/" + size + ".jpg"
+}
+
+// generate downloads an image, stores it at every size and returns the
+// thumbnail URL. Thumbnails are addressed by the source image's hash, so
+// links sharing an image share its files. Failures retrying can't fix wrap
+// ErrPermanent.
+func (s *ThumbnailService) generate(ctx context.Context, imageURL string) (string, error) {
+	data, err := s.download(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+	w, h, _, err := imaging.Dimensions(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	if w == 0 || h == 0 || w*h > maxThumbnailPixels {
+		return "", fmt.Errorf("image is %dx%d: %w", w, h, ErrPermanent)
+	}
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	for size, px := range ThumbnailSizes {
+		encoded, err := imaging.EncodeJPEG(imaging.Flatten(imaging.Fit(img, px), color.White), thumbnailQuality)
+		if err != nil {
+			return "", err
+		}
+		if err := s.store.Put(ctx, thumbnailKey(hash, size), encoded); err != nil {
+			return "", err
+		}
+	}
+	return ThumbnailPathPrefix + hash, nil
+}
+
+func (s *ThumbnailService) download(ctx context.Context, imageURL string) ([]byte, error) {
+	// Preview images are often larger than the guard's default cap.
+	ctx = netguard.WithMaxBodyBytes(ctx, maxThumbnailSourceBytes)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	resp, err := s.client.Do(req)
+	if errors.Is(err, netguard.ErrBlocked) || errors.Is(err, fetch.ErrDisallowed) {
+		return nil, fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return nil, fmt.Errorf("unexpected status %d: %w", resp.StatusCode, ErrPermanent)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxThumbnailSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxThumbnailSourceBytes {
+		return nil, fmt.Errorf("image larger than %d bytes: %w", maxThumbnailSourceBytes, ErrPermanent)
+	}
+	return data, nil
+}
+
+type FetchThumbnailPayload struct {
+	LinkID string `json:"link_id"`
+}
+
+// HandleFetchThumbnailJob is the JobFetchThumbnail handler. It does nothing
+// when the link's image_url was already tried, so it is safe to queue after
+// every enrichment.
+func (s *ThumbnailService) HandleFetchThumbnailJob(ctx context.Context, job models.Job) (any, error) {
+	var p FetchThumbnailPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %v: %w", err, ErrPermanent)
+	}
+	if job.OwnerID == nil {
+		return nil, fmt.Errorf("job has no owner: %w", ErrPermanent)
+	}
+	src, err := s.repo.Source(ctx, p.LinkID, *job.OwnerID)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !src.Pending() {
+		return nil, nil
+	}
+
+	thumbnailURL, err := s.generate(ctx, src.ImageURL)
+	if errors.Is(err, ErrPermanent) {
+		// Remember the attempt so the backfill doesn't queue it again.
+		if err := s.repo.SetLinkThumbnail(ctx, src.LinkID, src.ImageURL, ""); err != nil && !IsNotFound(err) {
+			return nil, err
+		}
+		slog.Warn("thumbnails: image unusable", "linkID", src.LinkID, "imageURL", src.ImageURL, "error", err)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetLinkThumbnail(ctx, src.LinkID, src.ImageURL, thumbnailURL); err != nil {
+		if IsNotFound(err) {
+			// image_url changed underneath us; its own job will follow.
+			return nil, nil
+		}
+		return nil, err
+	}
+	slog.Info("thumbnails: stored link thumbnail", "linkID", src.LinkID, "source", src.ImageURL, "thumbnailURL", thumbnailURL)
+	return map[string]string{"thumbnail_url": thumbnailURL}, nil
+}
+
+// QueueBackfill schedules a JobBackfillThumbnails run unless one is already
+// queued; while one is running, the new run follows it.
+func (s *ThumbnailService) QueueBackfill(ctx context.Context) (models.Job, error) {
+	return s.jobs.Enqueue(ctx, "", JobBackfillThumbnails, struct{}{}, EnqueueOptions{DedupeKey: JobBackfillThumbnails, MaxAttempts: 3})
+}
+
+// HandleBackfillThumbnailsJob is the JobBackfillThumbnails handler: it
+// queues a JobFetchThumbnail for every link whose image_url hasn't been
+// tried, such as links enriched before thumbnails existed.
+func (s *ThumbnailService) HandleBackfillThumbnailsJob(ctx context.Context, job models.Job) (any, error) {
+	const batchSize = 200
+	after := "00000000-0000-0000-0000-000000000000"
+	queued := 0
+	for {
+		pending, err := s.repo.Pending(ctx, after, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, src := range pending {
+			if _, err := s.jobs.Enqueue(ctx, src.OwnerID, JobFetchThumbnail, FetchThumbnailPayload{LinkID: src.LinkID}, EnqueueOptions{DedupeKey: src.LinkID, MaxAttempts: 3}); err != nil {
+				return nil, err
+			}
+			after = src.LinkID
+			queued++
+		}
+		if len(pending) < batchSize {
+			break
+		}
+	}
+	if queued > 0 {
+		slog.Info("thumbnails: backfill queued", "count", queued)
+	}
+	return map[string]int{"queued": queued}, nil
+}
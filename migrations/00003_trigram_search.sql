@@ -0,0 +1,13 @@
+-- +goose Up
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes back the fuzzy search fallback (similarity / word_similarity)
+-- and URL substring matching (ILIKE '%...%').
+CREATE INDEX idx_links_title_trgm ON links USING GIN (title gin_trgm_ops);
+CREATE INDEX idx_links_url_trgm ON links USING GIN (url gin_trgm_ops);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_url_trgm;
+DROP INDEX IF EXISTS idx_links_title_trgm;
@@ -0,0 +1,16 @@
+-- +goose Up
+
+-- Embeddings cover title, description and notes. embedding_model records which
+-- provider produced the vector so different embedding spaces are never mixed.
+ALTER TABLE links ADD COLUMN embedding vector(1536);
+ALTER TABLE links ADD COLUMN embedding_model text;
+ALTER TABLE links ADD COLUMN embedded_at timestamptz;
+
+CREATE INDEX idx_links_embedding ON links USING hnsw (embedding vector_cosine_ops);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_embedding;
+ALTER TABLE links DROP COLUMN IF EXISTS embedded_at;
+ALTER TABLE links DROP COLUMN IF EXISTS embedding_model;
+ALTER TABLE links DROP COLUMN IF EXISTS embedding;
@@ -0,0 +1,106 @@
+-- +goose Up
+
+-- Saved searches. filters is a JSON SmartFilter (see internal/models) that is
+-- evaluated live against links on every request.
+CREATE TABLE smart_collections (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  owner_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name text NOT NULL,
+  description text NOT NULL DEFAULT '',
+  filters jsonb NOT NULL DEFAULT '{}',
+  is_builtin boolean NOT NULL DEFAULT false,
+  display_order int NOT NULL DEFAULT 0,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE(owner_id, name)
+);
+
+CREATE INDEX idx_smart_collections_owner ON smart_collections(owner_id);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION ensure_user_defaults(user_id uuid) RETURNS void AS $$
+DECLARE
+  default_project_id uuid;
+  default_category_id uuid;
+BEGIN
+  -- Create default project if it doesn't exist
+  INSERT INTO projects (owner_id, name, description, is_default, display_order)
+  VALUES (user_id, 'Default', 'Default project', true, 0)
+  ON CONFLICT (owner_id, name) DO NOTHING
+  RETURNING id INTO default_project_id;
+
+  -- Get the project id if it already existed
+  IF default_project_id IS NULL THEN
+    SELECT id INTO default_project_id 
+    FROM projects 
+    WHERE owner_id = user_id AND is_default = true;
+  END IF;
+
+  -- Create default category if it doesn't exist
+  INSERT INTO categories (project_id, name, is_default, display_order)
+  VALUES (default_project_id, 'Unsorted', true, 0)
+  ON CONFLICT (project_id, name) DO NOTHING
+  RETURNING id INTO default_category_id;
+
+  -- Get the category id if it already existed
+  IF default_category_id IS NULL THEN
+    SELECT id INTO default_category_id 
+    FROM categories 
+    WHERE project_id = default_project_id AND is_default = true;
+  END IF;
+
+  -- Built-in smart collections
+  INSERT INTO smart_collections (owner_id, name, description, filters, is_builtin, display_order)
+  VALUES
+    (user_id, 'Highly rated, not visited in 30 days', 'Rated 7+ and not opened for a month',
+      '{"min_stars": 7, "not_visited_days": 30, "added_before_days": 30}', true, 0),
+    (user_id, 'Recently added, unrated', 'Added in the last 14 days without a rating',
+      '{"max_stars": 0, "added_within_days": 14, "sort": "created"}', true, 1),
+    (user_id, 'Never opened', 'Saved but never clicked',
+      '{"max_clicks": 0, "sort": "created"}', true, 2)
+  ON CONFLICT (owner_id, name) DO NOTHING;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Seed the built-ins for users that already exist.
+SELECT ensure_user_defaults(id) FROM users;
+
+-- +goose Down
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION ensure_user_defaults(user_id uuid) RETURNS void AS $$
+DECLARE
+  default_project_id uuid;
+  default_category_id uuid;
+BEGIN
+  -- Create default project if it doesn't exist
+  INSERT INTO projects (owner_id, name, description, is_default, display_order)
+  VALUES (user_id, 'Default', 'Default project', true, 0)
+  ON CONFLICT (owner_id, name) DO NOTHING
+  RETURNING id INTO default_project_id;
+
+  -- Get the project id if it already existed
+  IF default_project_id IS NULL THEN
+    SELECT id INTO default_project_id 
+    FROM projects 
+    WHERE owner_id = user_id AND is_default = true;
+  END IF;
+
+  -- Create default category if it doesn't exist
+  INSERT INTO categories (project_id, name, is_default, display_order)
+  VALUES (default_project_id, 'Unsorted', true, 0)
+  ON CONFLICT (project_id, name) DO NOTHING
+  RETURNING id INTO default_category_id;
+
+  -- Get the category id if it already existed
+  IF default_category_id IS NULL THEN
+    SELECT id INTO default_category_id 
+    FROM categories 
+    WHERE project_id = default_project_id AND is_default = true;
+  END IF;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TABLE smart_collections;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Registrable domain (eTLD+1) of each link's URL, computed by the API.
+-- Existing rows start empty and are backfilled on API startup.
+ALTER TABLE links ADD COLUMN domain text NOT NULL DEFAULT '';
+
+CREATE INDEX idx_links_domain ON links(owner_id, domain);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_domain;
+ALTER TABLE links DROP COLUMN IF EXISTS domain;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- One cached summary per link and size. url records the address the notes
+-- were generated from so a changed link URL invalidates them.
+CREATE TABLE generated_notes_cache (
+  link_id uuid NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+  size text NOT NULL CHECK (size IN ('tiny', 'short', 'medium', 'long')),
+  url text NOT NULL,
+  notes text NOT NULL,
+  summarizer text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (link_id, size)
+);
+
+-- +goose Down
+
+DROP TABLE generated_notes_cache;
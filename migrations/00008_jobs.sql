@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- Durable background jobs. Workers claim queued rows with
+-- SELECT ... FOR UPDATE SKIP LOCKED; failures are retried with backoff until
+-- max_attempts, after which the job is dead-lettered (status 'dead').
+CREATE TABLE jobs (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  owner_id uuid REFERENCES users(id) ON DELETE CASCADE,
+  kind text NOT NULL,
+  payload jsonb NOT NULL DEFAULT '{}',
+  status text NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'dead')),
+  attempts int NOT NULL DEFAULT 0,
+  max_attempts int NOT NULL DEFAULT 5,
+  last_error text,
+  result jsonb,
+  dedupe_key text,
+  run_at timestamptz NOT NULL DEFAULT now(),
+  locked_at timestamptz,
+  locked_by text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  finished_at timestamptz
+);
+
+CREATE INDEX idx_jobs_runnable ON jobs(run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running ON jobs(locked_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_owner ON jobs(owner_id, created_at DESC);
+
+-- At most one pending or running job per (kind, dedupe_key).
+CREATE UNIQUE INDEX idx_jobs_dedupe ON jobs(kind, dedupe_key)
+  WHERE dedupe_key IS NOT NULL AND status IN ('queued', 'running');
+
+-- +goose Down
+
+DROP TABLE jobs;
@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Progress of background metadata enrichment (title, description, icon).
+-- Existing links were enriched synchronously and start as 'ready'.
+ALTER TABLE links ADD COLUMN metadata_status text NOT NULL DEFAULT 'ready'
+  CHECK (metadata_status IN ('pending', 'ready', 'failed'));
+
+-- +goose Down
+
+ALTER TABLE links DROP COLUMN IF EXISTS metadata_status;
@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- Structured metadata from oEmbed, JSON-LD and OpenGraph, filled by
+-- background enrichment. content_type is one of
+-- article|video|repo|paper|website, or '' when unknown.
+ALTER TABLE links
+  ADD COLUMN site_name text NOT NULL DEFAULT '',
+  ADD COLUMN author text NOT NULL DEFAULT '',
+  ADD COLUMN published_at timestamptz,
+  ADD COLUMN content_type text NOT NULL DEFAULT '',
+  ADD COLUMN duration_seconds int,
+  ADD COLUMN image_url text NOT NULL DEFAULT '';
+
+CREATE INDEX idx_links_content_type ON links(owner_id, content_type);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_content_type;
+ALTER TABLE links
+  DROP COLUMN IF EXISTS site_name,
+  DROP COLUMN IF EXISTS author,
+  DROP COLUMN IF EXISTS published_at,
+  DROP COLUMN IF EXISTS content_type,
+  DROP COLUMN IF EXISTS duration_seconds,
+  DROP COLUMN IF EXISTS image_url;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- Fetched page metadata keyed by canonical URL, shared across users. Failed
+-- fetches are cached too (error set, meta NULL) with a shorter TTL. etag and
+-- last_modified are sent back as validators when an entry is revalidated.
+CREATE TABLE url_metadata_cache (
+  url text PRIMARY KEY,
+  meta jsonb,
+  error text,
+  etag text NOT NULL DEFAULT '',
+  last_modified text NOT NULL DEFAULT '',
+  fetched_at timestamptz NOT NULL DEFAULT now(),
+  expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX idx_url_metadata_cache_expires ON url_metadata_cache(expires_at);
+
+-- +goose Down
+
+DROP TABLE url_metadata_cache;
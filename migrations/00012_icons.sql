@@ -0,0 +1,31 @@
+-- +goose Up
+
+-- Favicons downloaded, validated and resized by the API, stored once per
+-- content hash and served from /api/v1/icons/{hash}.
+CREATE TABLE icons (
+  hash text PRIMARY KEY,
+  content_type text NOT NULL,
+  data bytea NOT NULL,
+  width int NOT NULL DEFAULT 0,
+  height int NOT NULL DEFAULT 0,
+  source_url text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Admin-maintained icon sources for sites whose favicon discovery is broken
+-- or non-standard. domain is the registrable domain (links.domain).
+CREATE TABLE icon_overrides (
+  domain text PRIMARY KEY,
+  icon_url text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Previously hard-coded in MetadataService.
+INSERT INTO icon_overrides (domain, icon_url)
+VALUES ('leetcode.com', 'https://assets.leetcode.com/static_assets/public/icons/favicon.ico');
+
+-- +goose Down
+
+DROP TABLE icon_overrides;
+DROP TABLE icons;
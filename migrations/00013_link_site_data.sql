@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- Site-specific attributes from metadata extractors (GitHub stars and
+-- language, arXiv ID, Hacker News points, ...), replaced on each enrichment.
+ALTER TABLE links ADD COLUMN site_data jsonb NOT NULL DEFAULT '{}';
+
+-- +goose Down
+
+ALTER TABLE links DROP COLUMN IF EXISTS site_data;
@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- Readable main text of the page, extracted during enrichment, indexed at the
+-- lowest weight so matches in it rank below title, description and notes.
+ALTER TABLE links
+  ADD COLUMN content_text text NOT NULL DEFAULT '',
+  ADD COLUMN word_count int NOT NULL DEFAULT 0;
+
+-- A generated column's expression can't be altered in place.
+DROP INDEX IF EXISTS idx_links_fts;
+ALTER TABLE links DROP COLUMN fts;
+ALTER TABLE links ADD COLUMN fts tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+  setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+  setweight(to_tsvector('english', coalesce(user_notes, '')), 'C') ||
+  setweight(to_tsvector('english', coalesce(generated_notes, '')), 'D') ||
+  setweight(to_tsvector('english', coalesce(content_text, '')), 'D')
+) STORED;
+CREATE INDEX idx_links_fts ON links USING GIN(fts);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_fts;
+ALTER TABLE links DROP COLUMN fts;
+ALTER TABLE links ADD COLUMN fts tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+  setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+  setweight(to_tsvector('english', coalesce(user_notes, '')), 'C') ||
+  setweight(to_tsvector('english', coalesce(generated_notes, '')), 'D')
+) STORED;
+CREATE INDEX idx_links_fts ON links USING GIN(fts);
+
+ALTER TABLE links
+  DROP COLUMN IF EXISTS content_text,
+  DROP COLUMN IF EXISTS word_count;
@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- Media type and size of the response behind a link, so PDFs, images and
+-- other non-HTML links can be told apart from pages.
+ALTER TABLE links
+  ADD COLUMN mime_type text NOT NULL DEFAULT '',
+  ADD COLUMN content_length bigint;
+
+-- +goose Down
+
+ALTER TABLE links
+  DROP COLUMN IF EXISTS content_length,
+  DROP COLUMN IF EXISTS mime_type;
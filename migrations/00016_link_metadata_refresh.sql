@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- When enrichment last ran for a link (success, or the final failed attempt),
+-- so stale metadata can be refreshed, and which of title, description and
+-- icon_url the user set by hand, so refreshes leave them alone.
+ALTER TABLE links
+  ADD COLUMN metadata_fetched_at timestamptz,
+  ADD COLUMN user_edited_fields text[] NOT NULL DEFAULT '{}';
+
+UPDATE links SET metadata_fetched_at = updated_at WHERE metadata_status <> 'pending';
+
+-- Existing titles and descriptions can't be told apart from fetched ones, so
+-- they are kept. Refreshing with ?reset= hands a field back to enrichment.
+UPDATE links SET user_edited_fields = array_remove(ARRAY[
+    CASE WHEN COALESCE(title, '') <> '' THEN 'title' END,
+    CASE WHEN COALESCE(description, '') <> '' THEN 'description' END
+  ], NULL);
+
+CREATE INDEX idx_links_metadata_fetched_at ON links(metadata_fetched_at NULLS FIRST);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_metadata_fetched_at;
+ALTER TABLE links
+  DROP COLUMN IF EXISTS user_edited_fields,
+  DROP COLUMN IF EXISTS metadata_fetched_at;
@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- Preview thumbnails generated from image_url (og:image). thumbnail_url
+-- points at the stored copy; thumbnail_source is the image_url it was made
+-- from, or last tried, so each image is only attempted once.
+ALTER TABLE links
+  ADD COLUMN thumbnail_url text NOT NULL DEFAULT '',
+  ADD COLUMN thumbnail_source text NOT NULL DEFAULT '';
+
+CREATE INDEX idx_links_thumbnail_pending ON links(id)
+  WHERE image_url <> '' AND thumbnail_source <> image_url;
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_links_thumbnail_pending;
+ALTER TABLE links
+  DROP COLUMN IF EXISTS thumbnail_source,
+  DROP COLUMN IF EXISTS thumbnail_url;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Dedupe against queued jobs only. A job enqueued while its duplicate is
+-- running must still run afterwards: the running one may already have read
+-- the state the new request is about. Claim keeps same-key jobs from
+-- running at once.
+DROP INDEX IF EXISTS idx_jobs_dedupe;
+CREATE UNIQUE INDEX idx_jobs_dedupe ON jobs(kind, dedupe_key)
+  WHERE dedupe_key IS NOT NULL AND status = 'queued';
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_jobs_dedupe;
+CREATE UNIQUE INDEX idx_jobs_dedupe ON jobs(kind, dedupe_key)
+  WHERE dedupe_key IS NOT NULL AND status IN ('queued', 'running');
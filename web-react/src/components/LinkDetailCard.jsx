@@ -58,6 +58,17 @@ export default function LinkDetailCard({ link, onEdit }) {
                 </button>
             </div>
 
+            {link.thumbnail_url && (
+                <img
+                    className="link-detail-thumbnail"
+                    src={`${link.thumbnail_url}?size=medium`}
+                    srcSet={`${link.thumbnail_url}?size=medium 1x, ${link.thumbnail_url}?size=large 2x`}
+                    alt=""
+                    loading="lazy"
+                    onError={(e) => { e.target.style.display = 'none'; }}
+                />
+            )}
+
             <div className="link-detail-title">{link.title || link.url}</div>
             <div className="link-detail-url">{cleanUrl(link.url)}</div>
 
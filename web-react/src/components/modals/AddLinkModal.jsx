@@ -20,28 +20,36 @@ export default function AddLinkModal({ projectId, categories, initialCategoryId,
     const [stars, setStars] = useState(0);
     const [error, setError] = useState('');
     const [loadingTitle, setLoadingTitle] = useState(false);
+    const [preview, setPreview] = useState(null);
 
     async function handleUrlBlur() {
-        // Only fetch if URL is valid and title is empty
-        if (!url.trim() || title.trim()) return;
-
         const normalizedUrl = normalizeUrl(url);
-        if (!normalizedUrl) return;
+        if (!normalizedUrl || preview?.url === normalizedUrl) return;
 
         setLoadingTitle(true);
         try {
-            const result = await api.fetchTitle(normalizedUrl);
-            if (result && result.title && !title.trim()) {
+            const result = await api.fetchPreview(normalizedUrl);
+            setPreview(result);
+            if (result?.title && !title.trim()) {
                 setTitle(result.title);
             }
+            if (result?.description && !description.trim()) {
+                setDescription(result.description);
+            }
         } catch (err) {
-            console.error('Failed to fetch title:', err);
+            setPreview(null);
+            console.error('Failed to fetch preview:', err);
             // Silent failure - user can still enter title manually
         } finally {
             setLoadingTitle(false);
         }
     }
 
+    function applyFinalUrl() {
+        setUrl(preview.final_url);
+        setPreview({ ...preview, url: preview.final_url, redirected: false });
+    }
+
     function handleCategoryInput(e) {
         const value = e.target.value;
         setCategoryInput(value);
@@ -57,7 +65,7 @@ export default function AddLinkModal({ projectId, categories, initialCategoryId,
         const catId = selectedCategoryId || (categories || []).find((c) => c.name === categoryInput.trim())?.id || null;
 
         try {
-            await api.createLink({
+            const link = await api.createLink({
                 url: normalizedUrl,
                 title,
                 description,
@@ -67,6 +75,10 @@ export default function AddLinkModal({ projectId, categories, initialCategoryId,
                 category_id: catId,
             });
             onCreated();
+            if (link?.metadata_status === 'pending') {
+                // Refresh again once the title and icon arrive.
+                api.waitForLinkMetadata(link.id).then((meta) => meta && onCreated()).catch(() => {});
+            }
         } catch (err) {
             setError('Failed to save link: ' + err.message);
         }
@@ -88,6 +100,13 @@ export default function AddLinkModal({ projectId, categories, initialCategoryId,
                             placeholder="www.example.com or https://example.com"
                             autoFocus
                         />
+                        {preview?.redirected && preview.url === normalizeUrl(url) && (
+                            <p className="modal-warning">
+                                {preview.cross_site ? 'Redirects to another site: ' : 'Redirects to '}
+                                {preview.final_url}{' '}
+                                <button type="button" className="btn btn-ghost" onClick={applyFinalUrl}>Use this URL</button>
+                            </p>
+                        )}
                     </div>
                     <div className="input-group">
                         <label>Title (Optional)</label>
@@ -93,6 +93,20 @@ class ApiClient {
         });
     }
 
+    getLinkMetadata(linkId) {
+        return this.request(`/links/${linkId}/metadata`);
+    }
+
+    // Polls background title/description/icon enrichment until it settles.
+    async waitForLinkMetadata(linkId, { interval = 1500, attempts = 20 } = {}) {
+        for (let i = 0; i < attempts; i++) {
+            await new Promise((resolve) => setTimeout(resolve, interval));
+            const meta = await this.getLinkMetadata(linkId);
+            if (meta.metadata_status !== 'pending') return meta;
+        }
+        return null;
+    }
+
     updateLink(linkId, linkData) {
         return this.request(`/links/${linkId}`, {
             method: 'PUT',
@@ -126,6 +140,10 @@ class ApiClient {
         return this.request(`/meta/title?url=${encodeURIComponent(url)}`);
     }
 
+    async fetchPreview(url) {
+        return this.request(`/meta/preview?url=${encodeURIComponent(url)}`);
+    }
+
     getMe() {
         return this.request('/auth/me');
     }